@@ -203,6 +203,21 @@ const EntryComponent = React.memo(
               This response was delayed by <span>{value.context.delay}</span>
             </Typography.Paragraph>
           )}
+          {value.context.closest?.map((report) => (
+            <div className="closest" key={report.mock_id}>
+              <Link to={`/pages/mocks/${report.mock_id}`}>Closest mock</Link>
+              {` matched ${report.matched} of ${report.total} criteria:`}
+              <ul>
+                {report.mismatches?.map((mismatch) => (
+                  <li key={mismatch.field}>
+                    <strong>{mismatch.field}</strong>
+                    {mismatch.matcher &&
+                      `: expected ${mismatch.matcher} "${mismatch.expected ?? ""}", got "${mismatch.actual ?? ""}"`}
+                  </li>
+                ))}
+              </ul>
+            </div>
+          ))}
         </div>
       </div>
     );
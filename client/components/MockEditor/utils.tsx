@@ -1,17 +1,46 @@
 export const defaultResponseStatus = 200;
 
-export const unaryMatchers = ["ShouldBeEmpty", "ShouldNotBeEmpty"];
+export const unaryMatchers = [
+  "ShouldBeEmpty",
+  "ShouldNotBeEmpty",
+  "ShouldBeTrue",
+  "ShouldBeFalse",
+  "ShouldBeNull",
+  "ShouldNotBeNull",
+  "ShouldBeString",
+  "ShouldBeNumber",
+  "ShouldBeBoolean",
+  "ShouldBeArray",
+  "ShouldBeObject",
+];
 
 export const positiveMatchers = [
   "ShouldEqual",
   "ShouldMatch",
+  "ShouldMatchPathTemplate",
+  "ShouldBeInCIDR",
   "ShouldEqualJSON",
+  "ShouldContainJSON",
   "ShouldContainSubstring",
   "ShouldStartWith",
   "ShouldEndWith",
   "ShouldResemble",
   "ShouldAlmostEqual",
   "ShouldBeEmpty",
+  "ShouldBeGreaterThan",
+  "ShouldBeGreaterThanOrEqualTo",
+  "ShouldBeLessThan",
+  "ShouldBeLessThanOrEqualTo",
+  "ShouldBeBetween",
+  "ShouldHaveLength",
+  "ShouldBeTrue",
+  "ShouldBeFalse",
+  "ShouldBeNull",
+  "ShouldBeString",
+  "ShouldBeNumber",
+  "ShouldBeBoolean",
+  "ShouldBeArray",
+  "ShouldBeObject",
 ];
 
 export const negativeMatchers = [
@@ -23,6 +52,8 @@ export const negativeMatchers = [
   "ShouldNotResemble",
   "ShouldNotAlmostEqual",
   "ShouldNotBeEmpty",
+  "ShouldNotBeBetween",
+  "ShouldNotBeNull",
 ];
 
 // Grouped options covering every matcher of the mock format, for any matcher <Select>.
@@ -51,6 +51,7 @@ import {
 import {
   bodyToString,
   formatHeaderValue,
+  formatMatcher,
   formatQueryParams,
   isStringMatcher,
   scrollToPage,
@@ -168,7 +169,7 @@ const MockRequestComponent = ({ request }: { request: MockRequest }) => {
   const showBody = isBodyStringMatcher && bodyToString(request.body);
   const path =
     (showPathMatcher
-      ? `Path: ${request.path.matcher} "${request.path.value}"`
+      ? `Path: ${formatMatcher(request.path)}`
       : request.path.value) + formatQueryParams(request.query_params);
   return (
     <div className="request">
@@ -176,7 +177,7 @@ const MockRequestComponent = ({ request }: { request: MockRequest }) => {
         <div className="group">
           <Tag color="blue">
             {showMethodMatcher
-              ? `Method: ${request.method.matcher} "${request.method.value}"`
+              ? `Method: ${formatMatcher(request.method)}`
               : request.method.value}
           </Tag>
           <Typography.Text className="path" ellipsis title={path}>
@@ -199,7 +200,7 @@ const MockRequestComponent = ({ request }: { request: MockRequest }) => {
       {request.body && isBodyStringMatcher && (
         <>
           <strong className="body-matcher">
-            {`Body ${request.body["matcher"]}`}
+            {`Body ${request.body["matcher"] || formatMatcher(request.body as StringMatcher)}`}
           </strong>
           {showBody && (
             <Code value={bodyToString(request.body)} language="json" />
@@ -215,7 +216,7 @@ const MockRequestComponent = ({ request }: { request: MockRequest }) => {
             ).map(([key, value]) => (
               <li key={key}>
                 <strong>{`${key}`}</strong>
-                {`: ${value.matcher} "${value.value}"`}
+                {`: ${formatMatcher(value)}`}
               </li>
             ))}
           </ul>
@@ -21,11 +21,31 @@ export type Sessions = z.infer<typeof SessionsSchema>;
 const MultimapSchema = z.record(z.string(), z.array(z.string()));
 export type Multimap = z.infer<typeof MultimapSchema>;
 
-const StringMatcherSchema = z.object({
-  matcher: z.string(),
-  value: z.string(),
-});
-export type StringMatcher = z.infer<typeof StringMatcherSchema>;
+export type StringMatcher = {
+  matcher: string;
+  value: string;
+  arrays?: "ordered" | "unordered" | "contains";
+  any_of?: StringMatcher[];
+  all_of?: StringMatcher[];
+  not?: StringMatcher;
+};
+
+// Composite matchers (any_of, all_of, not) have no matcher nor value of their own: both are
+// defaulted to empty strings so that every StringMatcher can be read the same way.
+const StringMatcherSchema: z.ZodType<StringMatcher, unknown> = z.lazy(() =>
+  z
+    .union([
+      z.object({
+        matcher: z.string(),
+        value: z.string(),
+        arrays: z.enum(["ordered", "unordered", "contains"]).optional(),
+      }),
+      z.object({ any_of: z.array(StringMatcherSchema) }),
+      z.object({ all_of: z.array(StringMatcherSchema) }),
+      z.object({ not: StringMatcherSchema }),
+    ])
+    .transform((m) => ({ matcher: "", value: "", ...m })),
+);
 
 const StringMatcherSliceSchema = z.array(StringMatcherSchema);
 export type StringMatcherSlice = z.infer<typeof StringMatcherSliceSchema>;
@@ -36,25 +56,114 @@ export type StringMatcherMap = z.infer<typeof StringMatcherMapSchema>;
 const MultimapMatcherSchema = z.record(z.string(), StringMatcherSliceSchema);
 export type MultimapMatcher = z.infer<typeof MultimapMatcherSchema>;
 
+const XPathMatcherSchema = z.object({
+  xpath: StringMatcherMapSchema,
+  namespaces: z.record(z.string(), z.string()).optional(),
+});
+export type XPathMatcher = z.infer<typeof XPathMatcherSchema>;
+
+const JSONPathMatcherSchema = z.object({
+  json_path: z.record(
+    z.string(),
+    z.intersection(
+      StringMatcherSchema,
+      z.object({ quantifier: z.enum(["any", "all"]).optional() }),
+    ),
+  ),
+});
+export type JSONPathMatcher = z.infer<typeof JSONPathMatcherSchema>;
+
+const JSONSchemaMatcherSchema = z.object({
+  matcher: z.literal("ShouldMatchJSONSchema"),
+  value: z.unknown(),
+});
+export type JSONSchemaMatcher = z.infer<typeof JSONSchemaMatcherSchema>;
+
+// The map of JSON paths comes first: a body {not: ...} matches its "not" field, as on the server.
 const BodyMatcherSchema = z.union([
-  StringMatcherSchema,
   StringMatcherMapSchema,
+  StringMatcherSchema,
+  JSONPathMatcherSchema,
+  XPathMatcherSchema,
+  JSONSchemaMatcherSchema,
 ]);
 export type BodyMatcher = z.infer<typeof BodyMatcherSchema>;
 
+const MismatchSchema = z.object({
+  field: z.string(),
+  matcher: z.string().optional(),
+  expected: z.string().optional(),
+  actual: z.string().optional(),
+  message: z.string().optional(),
+});
+export type Mismatch = z.infer<typeof MismatchSchema>;
+
+const MatchReportSchema = z.object({
+  mock_id: z.string().optional(),
+  matched: z.number(),
+  total: z.number(),
+  mismatches: z.array(MismatchSchema).optional(),
+});
+export type MatchReport = z.infer<typeof MatchReportSchema>;
+
 const EntryContextSchema = z.object({
   mock_id: z.string().optional(),
   mock_type: z.string().optional(),
   delay: z.string().optional(),
+  total_delay: z.string().optional(),
+  fault: z.string().optional(),
+  response_index: z.number().optional(),
+  events: z
+    .array(
+      z.object({
+        id: z.string().optional(),
+        event: z.string().optional(),
+        data: z.string().optional(),
+        retry: z.number().optional(),
+        date: z.string(),
+      }),
+    )
+    .optional(),
+  frames: z
+    .array(
+      z.object({
+        direction: z.enum(["in", "out"]),
+        type: z.string(),
+        body: z.string().optional(),
+        body_base64: z.boolean().optional(),
+        code: z.number().optional(),
+        date: z.string(),
+      }),
+    )
+    .optional(),
+  closest: z.array(MatchReportSchema).optional(),
 });
 export type EntryContext = z.infer<typeof EntryContextSchema>;
 
 const EntryRequestSchema = z.object({
   path: z.string(),
+  path_params: z.record(z.string(), z.string()).optional(),
   method: z.string(),
   body: z.unknown().optional(),
+  body_base64: z.boolean().optional(),
+  content_encoding: z.string().optional(),
   query_params: MultimapSchema.optional(),
   headers: MultimapSchema.optional(),
+  jwt: z
+    .object({
+      header: z.record(z.string(), z.unknown()),
+      claims: z.record(z.string(), z.unknown()),
+    })
+    .optional(),
+  graphql: z
+    .object({
+      query: z.string(),
+      operation_name: z.string().optional(),
+      operation_type: z.string(),
+      fields: z.array(z.string()),
+      variables: z.record(z.string(), z.unknown()).optional(),
+    })
+    .optional(),
   date: z.string(),
 });
 export type EntryRequest = z.infer<typeof EntryRequestSchema>;
@@ -62,6 +171,7 @@ export type EntryRequest = z.infer<typeof EntryRequestSchema>;
 const EntryResponseSchema = z.object({
   status: z.number(),
   body: z.unknown().optional(),
+  body_base64: z.boolean().optional(),
   headers: MultimapSchema.optional(),
   date: z.string(),
 });
@@ -77,22 +187,109 @@ export type Entry = z.infer<typeof EntrySchema>;
 export const HistorySchema = z.array(EntrySchema);
 export type History = z.infer<typeof HistorySchema>;
 
+const StrictnessSchema = z.union([z.boolean(), z.literal("ordered")]);
+
 const MockRequestSchema = z.object({
   path: StringMatcherSchema,
   method: StringMatcherSchema,
   body: BodyMatcherSchema.optional(),
   query_params: MultimapMatcherSchema.optional(),
   headers: MultimapMatcherSchema.optional(),
+  cookies: MultimapMatcherSchema.optional(),
+  origin: StringMatcherSchema.optional(),
+  host: StringMatcherSchema.optional(),
+  scheme: StringMatcherSchema.optional(),
+  tls: z
+    .object({
+      server_name: StringMatcherSchema.optional(),
+      client_subject: StringMatcherSchema.optional(),
+    })
+    .optional(),
+  jwt: z
+    .object({
+      secret: z.string().optional(),
+      public_key: z.string().optional(),
+      header: StringMatcherMapSchema.optional(),
+      claims: StringMatcherMapSchema.optional(),
+    })
+    .optional(),
+  graphql: z
+    .object({
+      operation_name: StringMatcherSchema.optional(),
+      operation_type: StringMatcherSchema.optional(),
+      fields: StringMatcherSliceSchema.optional(),
+      variables: StringMatcherMapSchema.optional(),
+    })
+    .optional(),
+  query_params_strict: StrictnessSchema.optional(),
+  headers_strict: StrictnessSchema.optional(),
+  cookies_strict: StrictnessSchema.optional(),
 });
 export type MockRequest = z.infer<typeof MockRequestSchema>;
 
+const MockCookieSchema = z.object({
+  name: z.string(),
+  value: z.string(),
+  path: z.string().optional(),
+  domain: z.string().optional(),
+  expires: z.string().optional(),
+  max_age: z.number().optional(),
+  secure: z.boolean().optional(),
+  http_only: z.boolean().optional(),
+  same_site: z.string().optional(),
+});
+export type MockCookie = z.infer<typeof MockCookieSchema>;
+
+const SSEResponseSchema = z.object({
+  events: z
+    .array(
+      z.object({
+        id: z.string().optional(),
+        event: z.string().optional(),
+        data: z.string().optional(),
+        retry: z.number().optional(),
+        delay: z.unknown().optional(),
+      }),
+    )
+    .optional(),
+  keep_open: z.union([z.boolean(), z.string()]).optional(),
+});
+
 const MockResponseSchema = z.object({
   status: z.number(),
   body: z.unknown().optional(),
+  body_base64: z.string().optional(),
+  chunks: z
+    .array(
+      z.object({
+        body: z.string().optional(),
+        body_base64: z.string().optional(),
+        delay: z.unknown().optional(),
+      }),
+    )
+    .optional(),
+  sse: SSEResponseSchema.optional(),
+  delay: z.unknown().optional(),
+  total_delay: z.unknown().optional(),
+  bandwidth: z.number().optional(),
+  compress: z.enum(["auto", "gzip", "deflate", "br"]).optional(),
   headers: MultimapSchema.optional(),
+  cookies: z.array(MockCookieSchema).optional(),
 });
 export type MockResponse = z.infer<typeof MockResponseSchema>;
 
+const MockResponsesSchema = z.union([
+  z.array(MockResponseSchema),
+  z.object({
+    mode: z.enum(["sequence", "random"]).optional(),
+    loop: z.boolean().optional(),
+    list: z.array(
+      MockResponseSchema.extend({ weight: z.number().optional() }),
+    ),
+  }),
+]);
+export type MockResponses = z.infer<typeof MockResponsesSchema>;
+
 const MockDynamicResponseSchema = z.object({
   engine: z.enum([
     "go_template",
@@ -106,9 +303,61 @@ export type MockDynamicResponse = z.infer<typeof MockDynamicResponseSchema>;
 
 const MockProxySchema = z.object({
   host: z.string(),
+  delay: z.unknown().optional(),
+  total_delay: z.unknown().optional(),
+  bandwidth: z.number().optional(),
 });
 export type MockProxy = z.infer<typeof MockProxySchema>;
 
+const WebSocketFrameSchema = z.object({
+  body: z.string().optional(),
+  body_base64: z.string().optional(),
+  delay: z.unknown().optional(),
+});
+
+const WebSocketCloseSchema = z.object({
+  code: z.number().optional(),
+  reason: z.string().optional(),
+  delay: z.unknown().optional(),
+});
+
+const MockWebSocketSchema = z.object({
+  on_connect: z.array(WebSocketFrameSchema).optional(),
+  on_message: z
+    .array(
+      z.object({
+        body: StringMatcherSchema.optional(),
+        send: z.array(WebSocketFrameSchema).optional(),
+        close: WebSocketCloseSchema.optional(),
+      }),
+    )
+    .optional(),
+  pushes: z
+    .array(
+      z.object({
+        body: z.string().optional(),
+        body_base64: z.string().optional(),
+        every: z.unknown(),
+      }),
+    )
+    .optional(),
+  close: WebSocketCloseSchema.optional(),
+});
+export type MockWebSocket = z.infer<typeof MockWebSocketSchema>;
+
+const MockFaultSchema = z.object({
+  type: z.enum([
+    "connection_reset",
+    "empty_response",
+    "hang_after_headers",
+    "truncated_body",
+    "malformed_response",
+  ]),
+  probability: z.number().optional(),
+  duration: z.unknown().optional(),
+});
+export type MockFault = z.infer<typeof MockFaultSchema>;
+
 const MockContextSchema = z.object({
   times: z.number().optional(),
 });
@@ -125,8 +374,11 @@ export type MockState = z.infer<typeof MockStateSchema>;
 const MockSchema = z.object({
   request: MockRequestSchema,
   response: MockResponseSchema.optional(),
+  responses: MockResponsesSchema.optional(),
   dynamic_response: MockDynamicResponseSchema.optional(),
   proxy: MockProxySchema.optional(),
+  websocket: MockWebSocketSchema.optional(),
+  fault: z.union([MockFaultSchema, z.array(MockFaultSchema)]).optional(),
   context: MockContextSchema,
   state: MockStateSchema,
 });
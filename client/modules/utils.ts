@@ -7,6 +7,7 @@ import {
   Entry,
   EntryRequest,
   EntryResponse,
+  JSONSchemaMatcher,
   Multimap,
   MultimapMatcher,
   StringMatcher,
@@ -190,7 +191,8 @@ export const isStringMatcher = (body?: BodyMatcher): boolean => {
   if (!body) {
     return false;
   }
-  if ((body as StringMatcher).matcher) {
+  const matcher = body as StringMatcher;
+  if (matcher.matcher || matcher.any_of || matcher.all_of) {
     return true;
   }
   return false;
@@ -201,7 +203,10 @@ export const bodyToString = (body?: BodyMatcher): string => {
     return "";
   }
   if ((body as StringMatcher).matcher) {
-    return (body as StringMatcher).value.trim();
+    const value = (body as JSONSchemaMatcher).value;
+    return typeof value === "string"
+      ? value.trim()
+      : JSON.stringify(value, null, 2);
   }
   return "";
 };
@@ -232,20 +237,27 @@ export const formatQueryParams = (
   );
 };
 
+// Formats a matcher for display, e.g. `ShouldMatch: "^a"` or `AnyOf(a, b)`.
+export const formatMatcher = (matcher: StringMatcher): string => {
+  if (matcher.any_of) {
+    return `AnyOf(${matcher.any_of.map(formatMatcher).join(", ")})`;
+  }
+  if (matcher.all_of) {
+    return `AllOf(${matcher.all_of.map(formatMatcher).join(", ")})`;
+  }
+  if (matcher.not) {
+    return `Not(${formatMatcher(matcher.not)})`;
+  }
+  return matcher.matcher !== defaultMatcher
+    ? `${matcher.matcher}: "${matcher.value}"`
+    : `${matcher.value}`;
+};
+
 export const formatHeaderValue = (headerValue?: StringMatcherSlice): string => {
   if (!headerValue) {
     return "";
   }
-  return headerValue
-    .reduce((acc: string[], v) => {
-      const param =
-        v.matcher !== defaultMatcher
-          ? `${v.matcher}: "${v.value}"`
-          : `${v.value}`;
-      acc.push(param);
-      return acc;
-    }, [])
-    .join(", ");
+  return headerValue.map(formatMatcher).join(", ");
 };
 
 // window.basePath is injected by the Go index.html template at runtime; guard against it being
@@ -46,6 +46,8 @@ func parseConfig() (c config.Config) {
 	fs.BoolVar(&c.TLSEnable, "tls-enable", false, "Enable TLS using the provided certificate")
 	fs.StringVar(&c.TLSCertFile, "tls-cert-file", "/etc/smocker/tls/certs/cert.pem", "Path to TLS certificate file ")
 	fs.StringVar(&c.TLSKeyFile, "tls-private-key-file", "/etc/smocker/tls/private/key.pem", "Path to TLS key file")
+	fs.BoolVar(&c.TLSClientCert, "tls-client-cert", false,
+		"Request a client certificate on the mock server, so that mocks can match on tls.client_subject")
 
 	// Seed defaults from environment variables (SMOCKER_*); command-line flags below take
 	// precedence, matching the previous namsral/flag precedence (flag > env > default).
@@ -57,6 +57,7 @@ func Serve(config config.Config) {
 	mocksGroup.POST("", handler.AddMocks)
 	mocksGroup.POST("/lock", handler.LockMocks)
 	mocksGroup.POST("/unlock", handler.UnlockMocks)
+	mocksGroup.POST("/match", handler.MatchMock)
 	mocksGroup.PUT("/:id", handler.UpdateMock)
 	mocksGroup.DELETE("/:id", handler.DeleteMock)
 
@@ -113,6 +114,10 @@ func Serve(config config.Config) {
 			NextProtos:   []string{"http/1.1"},
 			Certificates: []tls.Certificate{certificate},
 		}
+		if config.TLSClientCert {
+			// Client certificates are requested but not verified, so that mocks can match on them.
+			mockServerEngine.TLSConfig.ClientAuth = tls.RequestClientCert
+		}
 	}
 
 	if err := serve(config.TLSEnable, adminServerEngine.Server, mockServerEngine); err != nil {
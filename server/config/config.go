@@ -12,6 +12,7 @@ type Config struct {
 	TLSEnable            bool
 	TLSCertFile          string
 	TLSKeyFile           string
+	TLSClientCert        bool
 	Build                Build
 }
 
@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/smocker-dev/smocker/server/services"
+	"github.com/smocker-dev/smocker/server/templates"
 	"github.com/smocker-dev/smocker/server/types"
 )
 
@@ -166,6 +168,81 @@ func (a *Admin) UnlockMocks(c echo.Context) error {
 	return c.JSON(http.StatusOK, mocks)
 }
 
+// MatchMock matches a request description against the mocks of a session the way the mock server
+// would, and returns the mock which would answer with its response. Nothing is recorded: the
+// history and the times count of the mocks are left untouched, and proxies are not called.
+func (a *Admin) MatchMock(c echo.Context) error {
+	sessionID := a.sessionIDFromQuery(c)
+	mocks, err := a.mocksServices.GetMocks(sessionID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	var description types.RequestDescription
+	if err := bindAccordingAccept(c, &description); err != nil {
+		return err
+	}
+	request, err := description.Request()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	mock, exceeded := mocks.FirstMatch(request)
+	result := types.MatchResult{Request: request, Exceeded: exceeded}
+	if mock == nil {
+		result.Message = types.SmockerMockNotFound
+		if len(exceeded) > 0 {
+			result.Message = types.SmockerMockExceeded
+		} else {
+			for _, closest := range types.ClosestMocks(mocks, request, closestMocksLimit) {
+				result.Closest = append(result.Closest, closest.Report)
+			}
+		}
+		return respondAccordingAccept(c, result)
+	}
+
+	for _, skipped := range mocks {
+		if skipped == mock {
+			break
+		}
+		if report := skipped.Request.Check(request); len(report.Mismatches) > 0 {
+			report.MockID = skipped.State.ID
+			result.Skipped = append(result.Skipped, report)
+		}
+	}
+
+	result.Message = "Mock found"
+	result.Mock = mock
+	result.Request.PathParams = mock.Request.Path.Captures(request.Path)
+	switch {
+	case mock.DynamicResponse != nil:
+		result.MockType = "dynamic"
+		response, err := templates.GenerateMockResponse(mock.DynamicResponse, result.Request)
+		if err != nil {
+			result.Error = fmt.Sprintf("%s: %v", types.SmockerEngineExecutionError, err)
+			break
+		}
+		result.Response = response
+	case mock.Proxy != nil:
+		result.MockType = "proxy"
+	case mock.WebSocket != nil:
+		result.MockType = "websocket"
+	case mock.Responses != nil:
+		result.MockType = "static"
+		index := mock.Responses.Pick(mock.State.TimesCount)
+		response := mock.Responses.List[index].MockResponse
+		result.Response, result.ResponseIndex = &response, &index
+	case mock.Response != nil:
+		result.MockType = "static"
+		response := *mock.Response
+		result.Response = &response
+	}
+	if result.Response != nil && result.Response.Status == 0 {
+		result.Response.Status = http.StatusOK
+	}
+	return respondAccordingAccept(c, result)
+}
+
 func (a *Admin) VerifySession(c echo.Context) error {
 	sessionID := c.QueryParam("session")
 	var session *types.Session
@@ -267,7 +344,7 @@ func (a *Admin) SummarizeSessions(c echo.Context) error {
 func (a *Admin) NewSession(c echo.Context) error {
 	name := c.QueryParam("name")
 	session := a.mocksServices.NewSession(name)
-	return respondAccordingAccept(c, types.SessionSummary(*session))
+	return respondAccordingAccept(c, session.Summarize())
 }
 
 type updateSessionBody struct {
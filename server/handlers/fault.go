@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/smocker-dev/smocker/server/types"
+)
+
+// malformedResponse is not valid HTTP: its status line has no status code.
+const malformedResponse = "HTTP/1.1 ???\r\nContent-Type: text/plain\r\n\r\nmalformed"
+
+// writeFault injects a network fault instead of the response, and drops the connection.
+func writeFault(c echo.Context, fault types.MockFault, response *types.MockResponse) error {
+	status := response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	switch fault.Type {
+	case types.FaultHangAfterHeaders:
+		c.Response().WriteHeader(status)
+		c.Response().Flush()
+		var timeout <-chan time.Time
+		if duration := fault.Duration.Duration(); duration > 0 {
+			timeout = time.After(duration)
+		}
+		select {
+		case <-c.Request().Context().Done():
+		case <-timeout:
+		}
+	case types.FaultTruncatedBody:
+		body, _ := response.BodyBytes()
+		length := len(body)
+		if length == 0 {
+			// Something must be missing.
+			length = 1
+		}
+		c.Response().Header().Set(echo.HeaderContentLength, strconv.Itoa(length))
+		c.Response().WriteHeader(status)
+		if _, err := c.Response().Write(body[:len(body)/2]); err != nil {
+			return err
+		}
+		c.Response().Flush()
+	default:
+		// Nothing is sent.
+		c.Response().Status = 0
+	}
+
+	conn, buf, err := c.Response().Hijack()
+	if err != nil {
+		return err
+	}
+	switch fault.Type {
+	case types.FaultConnectionReset:
+		// Closing a connection with unread data, or without lingering, sends a RST.
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			_ = tcpConn.SetLinger(0)
+		}
+	case types.FaultMalformedResponse:
+		_, _ = buf.WriteString(malformedResponse)
+		_ = buf.Flush()
+	}
+	return conn.Close()
+}
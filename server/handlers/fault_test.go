@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/smocker-dev/smocker/server/types"
+)
+
+func TestFaults(t *testing.T) {
+	var mocks strings.Builder
+	for _, faultType := range types.FaultTypes {
+		fmt.Fprintf(&mocks, `
+- request:
+    method: GET
+    path: /%[1]s
+  response:
+    status: 200
+    body: 0123456789
+  fault:
+    type: %[1]s
+`, faultType)
+		if faultType == types.FaultHangAfterHeaders {
+			mocks.WriteString("    duration: 50ms\n")
+		}
+	}
+	server, contexts := newMockServer(t, mocks.String())
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	get := func(faultType types.FaultType) (*http.Response, []byte, error) {
+		t.Helper()
+		resp, err := client.Get(server.URL + "/" + string(faultType))
+		if context := <-contexts; context == nil || context.Fault != faultType {
+			t.Errorf("%s: context = %+v", faultType, context)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp, body, err
+	}
+
+	for _, faultType := range []types.FaultType{types.FaultConnectionReset, types.FaultEmptyResponse} {
+		if _, _, err := get(faultType); err == nil {
+			t.Errorf("%s: expected an error", faultType)
+		}
+	}
+	if _, _, err := get(types.FaultMalformedResponse); err == nil || !strings.Contains(err.Error(), "malformed HTTP") {
+		t.Errorf("malformed_response: error = %v", err)
+	}
+
+	resp, body, err := get(types.FaultHangAfterHeaders)
+	if resp == nil || resp.StatusCode != http.StatusOK || len(body) != 0 || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("hang_after_headers: body = %q, error = %v", body, err)
+	}
+
+	resp, body, err = get(types.FaultTruncatedBody)
+	if resp == nil || resp.ContentLength != 10 || string(body) != "01234" || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated_body: body = %q, error = %v", body, err)
+	}
+}
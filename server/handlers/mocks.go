@@ -1,9 +1,9 @@
 package handlers
 
 import (
+	stdcontext "context"
 	"fmt"
 	"log/slog"
-	"math/rand/v2"
 	"net/http"
 	"sync"
 	"time"
@@ -15,6 +15,10 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// closestMocksLimit is the number of closest mocks, with the reasons they didn't match, listed
+// when no mock matches a request.
+const closestMocksLimit = 3
+
 type Mocks struct {
 	mocksServices services.Mocks
 	mu            sync.Mutex
@@ -28,6 +32,7 @@ func NewMocks(ms services.Mocks) *Mocks {
 }
 
 func (m *Mocks) GenericHandler(c echo.Context) error {
+	start := time.Now()
 	actualRequest := types.HTTPRequestToRequest(c.Request())
 	b, _ := yaml.Marshal(actualRequest)
 	slog.Debug(fmt.Sprintf("Received request:\n---\n%s\n", string(b)))
@@ -35,14 +40,12 @@ func (m *Mocks) GenericHandler(c echo.Context) error {
 	/* Request matching */
 
 	var (
-		matchingMock *types.Mock
-		response     *types.MockResponse
-		err          error
+		response *types.MockResponse
+		err      error
 	)
-	exceededMocks := types.Mocks{}
 	context := &types.Context{}
 	session := m.mocksServices.GetLastSession()
-	mocks, err := m.mocksServices.GetMocks(session.ID)
+	mocks, err := m.mocksServices.GetCandidateMocks(session.ID, actualRequest)
 	if err != nil {
 		return c.JSON(types.StatusSmockerInternalError, echo.Map{
 			"message": fmt.Sprintf("%s: %v", types.SmockerInternalError, err),
@@ -50,51 +53,65 @@ func (m *Mocks) GenericHandler(c echo.Context) error {
 		})
 	}
 
-	for _, mock := range mocks {
-		if mock.Request.Match(actualRequest) {
-			matchingMock = mock
-			if matchingMock.Context.Times > 0 && matchingMock.State.TimesCount >= matchingMock.Context.Times {
-				b, _ = yaml.Marshal(mock)
-				slog.Debug(fmt.Sprintf("Times exceeded, skipping mock:\n---\n%s\n", string(b)))
-				exceededMocks = append(exceededMocks, mock)
-				continue
+	matchingMock, exceededMocks := mocks.FirstMatch(actualRequest)
+	if mock := matchingMock; mock != nil {
+		// counted tells whether the call was already counted along with the pick of a response.
+		counted := false
+		context.MockID = mock.State.ID
+		actualRequest.PathParams = mock.Request.Path.Captures(actualRequest.Path)
+		if actualRequest.PathParams != nil {
+			c.Set(types.PathParamsKey, actualRequest.PathParams)
+		}
+		if mock.DynamicResponse != nil {
+			response, err = templates.GenerateMockResponse(mock.DynamicResponse, actualRequest)
+			context.MockType = "dynamic"
+			if err != nil {
+				c.Set(types.ContextKey, context)
+				return c.JSON(types.StatusSmockerEngineExecutionError, echo.Map{
+					"message": fmt.Sprintf("%s: %v", types.SmockerEngineExecutionError, err),
+					"request": actualRequest,
+				})
 			}
-
-			b, _ = yaml.Marshal(matchingMock)
-			slog.Debug(fmt.Sprintf("Matching mock:\n---\n%s\n", string(b)))
-			context.MockID = mock.State.ID
-			if mock.DynamicResponse != nil {
-				response, err = templates.GenerateMockResponse(mock.DynamicResponse, actualRequest)
-				context.MockType = "dynamic"
-				if err != nil {
-					c.Set(types.ContextKey, context)
-					return c.JSON(types.StatusSmockerEngineExecutionError, echo.Map{
-						"message": fmt.Sprintf("%s: %v", types.SmockerEngineExecutionError, err),
-						"request": actualRequest,
-					})
-				}
-			} else if mock.Proxy != nil {
-				response, err = mock.Proxy.Redirect(actualRequest)
-				context.MockType = "proxy"
-				if err != nil {
-					c.Set(types.ContextKey, context)
-					return c.JSON(types.StatusSmockerProxyRedirectionError, echo.Map{
-						"message": fmt.Sprintf("%s: %v", types.SmockerProxyRedirectionError, err),
-						"request": actualRequest,
-					})
-				}
-			} else if mock.Response != nil {
-				context.MockType = "static"
-				response = mock.Response
+		} else if mock.Proxy != nil {
+			response, err = mock.Proxy.Redirect(actualRequest)
+			context.MockType = "proxy"
+			if err != nil {
+				c.Set(types.ContextKey, context)
+				return c.JSON(types.StatusSmockerProxyRedirectionError, echo.Map{
+					"message": fmt.Sprintf("%s: %v", types.SmockerProxyRedirectionError, err),
+					"request": actualRequest,
+				})
 			}
+		} else if mock.Responses != nil {
+			context.MockType = "static"
+			// The response is picked along with the count of the call, so that concurrent calls
+			// get different responses of a sequence.
+			m.mu.Lock()
+			index := mock.Responses.Pick(mock.State.TimesCount)
+			mock.State.TimesCount++
+			m.mu.Unlock()
+			counted = true
+			context.ResponseIndex = &index
+			response = &mock.Responses.List[index].MockResponse
+		} else if mock.Response != nil {
+			context.MockType = "static"
+			response = mock.Response
+		}
 
+		if !counted {
 			m.mu.Lock()
 			matchingMock.State.TimesCount++
 			m.mu.Unlock()
-			break
-		} else {
-			b, _ = yaml.Marshal(mock)
-			slog.Debug(fmt.Sprintf("Skipping mock:\n---\n%s\n", string(b)))
+		}
+
+		if mock.WebSocket != nil {
+			context.MockType = "websocket"
+			c.Set(types.ContextKey, context)
+			if fault := mock.Fault.Pick(); fault != nil {
+				context.Fault = fault.Type
+				return writeFault(c, *fault, &types.MockResponse{})
+			}
+			return serveWebSocket(c, mock.WebSocket, context)
 		}
 	}
 
@@ -104,6 +121,17 @@ func (m *Mocks) GenericHandler(c echo.Context) error {
 			"request": actualRequest,
 		}
 
+		// The closest mocks are looked for among all of them, as the candidates only include mocks
+		// expecting the method and path of the request.
+		if allMocks, _ := m.mocksServices.GetMocks(session.ID); len(exceededMocks) == 0 && len(allMocks) > 0 {
+			closest := types.ClosestMocks(allMocks, actualRequest, closestMocksLimit)
+			for _, mock := range closest {
+				context.Closest = append(context.Closest, mock.Report)
+			}
+			resp["closest"] = closest
+			c.Set(types.ContextKey, context)
+		}
+
 		if len(exceededMocks) > 0 {
 			for _, mock := range exceededMocks {
 				m.mu.Lock()
@@ -121,6 +149,15 @@ func (m *Mocks) GenericHandler(c echo.Context) error {
 
 	/* Response writing */
 
+	// Static responses are validated along with their mock, dynamic ones are validated here.
+	if err = response.Validate(); err != nil {
+		c.Set(types.ContextKey, context)
+		return c.JSON(types.StatusSmockerEngineExecutionError, echo.Map{
+			"message": fmt.Sprintf("%s: %v", types.SmockerEngineExecutionError, err),
+			"request": actualRequest,
+		})
+	}
+
 	// Headers: assign to the header map directly instead of Add()/Set(), which canonicalize the
 	// key (e.g. "BrokerProperties" -> "Brokerproperties"). Smocker preserves the exact casing the
 	// mock declares, matching servers that treat header names case-sensitively.
@@ -128,21 +165,53 @@ func (m *Mocks) GenericHandler(c echo.Context) error {
 	for key, values := range response.Headers {
 		header[key] = []string(values)
 	}
+	if cookies := response.Cookies.SetCookieHeaders(); len(cookies) > 0 {
+		header["Set-Cookie"] = append(header["Set-Cookie"], cookies...)
+	}
+	if response.SSE != nil {
+		if header.Get(echo.HeaderContentType) == "" {
+			header.Set(echo.HeaderContentType, "text/event-stream")
+		}
+		if header.Get(echo.HeaderCacheControl) == "" {
+			header.Set(echo.HeaderCacheControl, "no-cache")
+		}
+	}
 
-	// Delay
-	var delay time.Duration
-	if response.Delay.Min != response.Delay.Max {
-		n := int64(response.Delay.Max - response.Delay.Min)
-		delay = time.Duration(rand.Int64N(n) + int64(response.Delay.Min))
-	} else {
-		delay = response.Delay.Min
+	// Compression
+	if response.Compress != "" {
+		header.Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+		if coding := response.Compress.Negotiate(c.Request().Header.Get(echo.HeaderAcceptEncoding)); coding != "" {
+			if response, err = response.Compressed(coding); err != nil {
+				c.Set(types.ContextKey, context)
+				return c.JSON(types.StatusSmockerInternalError, echo.Map{
+					"message": fmt.Sprintf("%s: %v", types.SmockerInternalError, err),
+					"request": actualRequest,
+				})
+			}
+			header.Set(echo.HeaderContentEncoding, coding)
+			header.Del(echo.HeaderContentLength)
+		}
 	}
+
+	// Delay
+	delay := response.Delay.Duration()
 	if delay > 0 {
 		context.Delay = delay.String()
 	}
 	time.Sleep(delay)
+	var totalDelay time.Duration
+	if response.TotalDelay != nil {
+		totalDelay = response.TotalDelay.Duration()
+		context.TotalDelay = totalDelay.String()
+	}
 	c.Set(types.ContextKey, context)
 
+	// Fault
+	if fault := matchingMock.Fault.Pick(); fault != nil {
+		context.Fault = fault.Type
+		return writeFault(c, *fault, response)
+	}
+
 	// Status
 	if response.Status == 0 {
 		// Fallback to 200 OK
@@ -151,7 +220,18 @@ func (m *Mocks) GenericHandler(c echo.Context) error {
 	c.Response().WriteHeader(response.Status)
 
 	// Body
-	if _, err = c.Response().Write([]byte(response.Body)); err != nil {
+	if response.SSE != nil {
+		err = writeEvents(c, *response.SSE, context)
+	} else if len(response.Chunks) > 0 {
+		err = writeChunks(c, response.Chunks)
+	} else if response.Bandwidth > 0 || totalDelay > 0 {
+		body, _ := response.BodyBytes()
+		err = writeThrottled(c, body, response.Bandwidth, start.Add(totalDelay))
+	} else {
+		body, _ := response.BodyBytes()
+		_, err = c.Response().Write(body)
+	}
+	if err != nil {
 		slog.Error("Failed to write response body", "error", err)
 		return echo.NewHTTPError(types.StatusSmockerInternalError, fmt.Sprintf("%s: %v", types.SmockerInternalError, err))
 	}
@@ -160,3 +240,107 @@ func (m *Mocks) GenericHandler(c echo.Context) error {
 	slog.Debug(fmt.Sprintf("Returned response:\n---\n%s\n", string(b)))
 	return nil
 }
+
+// writeEvents sends each server-sent event after its delay, records it in the context of the
+// history entry, and keeps the stream open as long as requested. It stops early when the client
+// goes away.
+func writeEvents(c echo.Context, sse types.SSEResponse, context *types.Context) error {
+	ctx := c.Request().Context()
+	c.Response().Flush()
+	for _, event := range sse.Events {
+		if !wait(ctx, event.Delay.Duration()) {
+			return nil
+		}
+		if _, err := c.Response().Write(event.Format()); err != nil {
+			return err
+		}
+		c.Response().Flush()
+		context.Events = append(context.Events, event.Sent())
+	}
+
+	if sse.KeepOpen != 0 {
+		var timeout <-chan time.Time
+		if sse.KeepOpen > 0 {
+			timeout = time.After(time.Duration(sse.KeepOpen))
+		}
+		select {
+		case <-ctx.Done():
+		case <-timeout:
+		}
+	}
+	return nil
+}
+
+// writeChunks writes each chunk after its delay and flushes it, so that the client receives it
+// right away. It stops early when the client goes away.
+func writeChunks(c echo.Context, chunks []types.ResponseChunk) error {
+	ctx := c.Request().Context()
+	for _, chunk := range chunks {
+		if !wait(ctx, chunk.Delay.Duration()) {
+			return nil
+		}
+		body, _ := chunk.BodyBytes()
+		if _, err := c.Response().Write(body); err != nil {
+			return err
+		}
+		c.Response().Flush()
+	}
+	return nil
+}
+
+// throttleInterval is the interval at which the parts of a throttled body are sent.
+const throttleInterval = 50 * time.Millisecond
+
+// writeThrottled writes the body in parts, flushed one after the other, so that it is sent at no
+// more than bandwidth bytes per second when bandwidth is set, and so that the response ends at
+// the deadline when it is still ahead. It stops early when the client goes away.
+func writeThrottled(c echo.Context, body []byte, bandwidth int, deadline time.Time) error {
+	ctx := c.Request().Context()
+	begin := time.Now()
+	spread := deadline.Sub(begin)
+	if spread < 0 {
+		spread = 0
+	}
+
+	size := len(body)
+	if parts := int(spread / throttleInterval); parts > 1 {
+		size = (len(body) + parts - 1) / parts
+	}
+	if bandwidth > 0 {
+		size = min(size, max(1, int(int64(bandwidth)*int64(throttleInterval)/int64(time.Second))))
+	}
+
+	for sent := 0; sent < len(body); {
+		part := body[sent:min(sent+size, len(body))]
+		if _, err := c.Response().Write(part); err != nil {
+			return err
+		}
+		c.Response().Flush()
+		sent += len(part)
+
+		next := begin.Add(time.Duration(float64(spread) * float64(sent) / float64(len(body))))
+		if bandwidth > 0 {
+			if limited := begin.Add(time.Duration(sent) * time.Second / time.Duration(bandwidth)); limited.After(next) {
+				next = limited
+			}
+		}
+		if !wait(ctx, time.Until(next)) {
+			return nil
+		}
+	}
+	wait(ctx, time.Until(deadline))
+	return nil
+}
+
+// wait waits for delay, and returns false when the client went away in the meantime.
+func wait(ctx stdcontext.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
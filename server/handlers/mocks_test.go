@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	stdcontext "context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/smocker-dev/smocker/server/services"
+	"github.com/smocker-dev/smocker/server/types"
+	"gopkg.in/yaml.v3"
+)
+
+// newMockServer serves the mocks of mockYAML, a single mock or a list of them, from a new
+// session. The context of each answered request is sent on the returned channel, when it has room
+// for it.
+func newMockServer(tb testing.TB, mockYAML string) (*httptest.Server, <-chan *types.Context) {
+	tb.Helper()
+	mocksServices, err := services.NewMocks(nil, 0, services.NewPersistence(""), "")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var mocks types.Mocks
+	if strings.HasPrefix(strings.TrimSpace(mockYAML), "-") {
+		err = yaml.Unmarshal([]byte(mockYAML), &mocks)
+	} else {
+		var mock types.Mock
+		err = yaml.Unmarshal([]byte(mockYAML), &mock)
+		mocks = types.Mocks{&mock}
+	}
+	if err != nil {
+		tb.Fatal(err)
+	}
+	sessionID := mocksServices.NewSession(tb.Name()).ID
+	for _, mock := range mocks {
+		if err := mock.Validate(); err != nil {
+			tb.Fatal(err)
+		}
+		if _, err := mocksServices.AddMock(sessionID, mock); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	contexts := make(chan *types.Context, 1)
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			context, _ := c.Get(types.ContextKey).(*types.Context)
+			select {
+			case contexts <- context:
+			default:
+			}
+			return err
+		}
+	})
+	e.Any("/*", NewMocks(mocksServices).GenericHandler)
+	server := httptest.NewServer(e)
+	tb.Cleanup(server.Close)
+	return server, contexts
+}
+
+// BenchmarkGenericHandler measures answering a request from a session holding many mocks, which
+// only the candidates given by the session index are matched against.
+func BenchmarkGenericHandler(b *testing.B) {
+	var mocks strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&mocks, `
+- request:
+    method: GET
+    path: {matcher: ShouldMatchPathTemplate, value: "/resources%d/{id}"}
+  response:
+    status: 200
+    body: ok
+`, i)
+	}
+	server, _ := newMockServer(b, mocks.String())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rec := httptest.NewRecorder()
+		server.Config.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resources2500/42", nil))
+		if rec.Code != http.StatusOK {
+			b.Fatalf("status = %d", rec.Code)
+		}
+	}
+}
+
+// TestChunkedResponse checks that each chunk reaches the client on its own, after its delay.
+func TestChunkedResponse(t *testing.T) {
+	server, _ := newMockServer(t, `
+request:
+  method: GET
+  path: /stream
+response:
+  status: 200
+  chunks:
+    - body: "first,"
+    - body: "second,"
+      delay: 100ms
+    - body_base64: dGhpcmQ=
+`)
+
+	start := time.Now()
+	resp, err := http.Get(server.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if len(resp.TransferEncoding) == 0 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("transfer encoding = %v, want chunked", resp.TransferEncoding)
+	}
+
+	first := make([]byte, len("first,"))
+	if _, err := io.ReadFull(resp.Body, first); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+		t.Errorf("first chunk received after %v, expected it before the delay of the second one", elapsed)
+	}
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := string(first) + string(rest); body != "first,second,third" {
+		t.Errorf("body = %q", body)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("body received after %v, expected the delay of the second chunk", elapsed)
+	}
+}
+
+func TestThrottledResponse(t *testing.T) {
+	const expected = "0123456789012345678901234567890123456789"
+	server, _ := newMockServer(t, `
+- request:
+    method: GET
+    path: /slow
+  response:
+    status: 200
+    body: "`+expected+`"
+    delay: 50ms
+    total_delay: 300ms
+    bandwidth: 200
+- request:
+    method: GET
+    path: /slower
+  response:
+    status: 200
+    body: "`+expected+`"
+    bandwidth: 100
+- request:
+    path: /invalid
+  dynamic_response:
+    engine: lua
+    script: return { body = "hi", delay = { min = "30ms", max = "10ms" } }
+`)
+
+	start := time.Now()
+	resp, err := http.Get(server.URL + "/slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	first := make([]byte, 1)
+	if _, err := io.ReadFull(resp.Body, first); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed >= 150*time.Millisecond {
+		t.Errorf("first byte received after %v, expected it after the delay", elapsed)
+	}
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := string(first) + string(rest); body != expected {
+		t.Errorf("body = %q", body)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond || elapsed >= 500*time.Millisecond {
+		t.Errorf("body received after %v, expected it after the total delay", elapsed)
+	}
+
+	// Without a total delay, the bandwidth alone paces the body.
+	start = time.Now()
+	resp, err = http.Get(server.URL + "/slower")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("body received after %v, expected 40 bytes at 100 bytes per second to take 400ms", elapsed)
+	}
+
+	// A dynamic response with an invalid delay is an engine error, not a panic.
+	resp, err = http.Get(server.URL + "/invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != types.StatusSmockerEngineExecutionError {
+		t.Errorf("status = %d, expected %d", resp.StatusCode, types.StatusSmockerEngineExecutionError)
+	}
+}
+
+func TestSSEResponse(t *testing.T) {
+	server, contexts := newMockServer(t, `
+- request:
+    method: GET
+    path: /notifications
+  response:
+    status: 200
+    sse:
+      keep_open: 50ms
+      events:
+        - id: "1"
+          event: created
+          data: |-
+            {"id": 1}
+            {"id": 2}
+          retry: 3000
+        - data: ping
+          delay: 10ms
+- request:
+    method: GET
+    path: /forever
+  response:
+    sse:
+      keep_open: true
+      events:
+        - data: ping
+`)
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	server.Config.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/notifications", nil))
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("stream closed after %v, expected it to be kept open", elapsed)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("content type = %q", contentType)
+	}
+	expected := "id: 1\nevent: created\nretry: 3000\ndata: {\"id\": 1}\ndata: {\"id\": 2}\n\ndata: ping\n\n"
+	if body := rec.Body.String(); body != expected {
+		t.Errorf("body = %q, want %q", body, expected)
+	}
+	context := <-contexts
+	if context == nil || len(context.Events) != 2 || context.Events[0].Event != "created" || context.Events[1].Data != "ping" {
+		t.Errorf("context = %+v", context)
+	}
+
+	// The stream kept open until the client disconnects is closed when it does.
+	ctx, cancel := stdcontext.WithTimeout(stdcontext.Background(), 50*time.Millisecond)
+	defer cancel()
+	rec = httptest.NewRecorder()
+	server.Config.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/forever", nil).WithContext(ctx))
+	if body := rec.Body.String(); body != "data: ping\n\n" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestCompressedResponse(t *testing.T) {
+	const expected = `{"message": "hello"}`
+	server, _ := newMockServer(t, `
+request:
+  method: GET
+  path: /compressed
+response:
+  status: 200
+  compress: auto
+  headers:
+    Content-Type: application/json
+  body: '`+expected+`'
+`)
+
+	for acceptEncoding, coding := range map[string]string{
+		"gzip, br": "br",
+		"gzip":     "gzip",
+		"":         "",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/compressed", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		server.Config.Handler.ServeHTTP(rec, req)
+		if encoding := rec.Header().Get("Content-Encoding"); encoding != coding {
+			t.Errorf("%q: content encoding = %q, want %q", acceptEncoding, encoding, coding)
+		}
+		if vary := rec.Header().Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("%q: vary = %q", acceptEncoding, vary)
+		}
+		body, err := types.DecodeContentEncoding(rec.Body.Bytes(), coding)
+		if err != nil {
+			t.Fatalf("%q: %v", acceptEncoding, err)
+		}
+		if string(body) != expected {
+			t.Errorf("%q: body = %q", acceptEncoding, body)
+		}
+	}
+}
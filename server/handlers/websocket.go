@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/smocker-dev/smocker/server/types"
+)
+
+// closeTimeout is how long the client is given to acknowledge a close frame.
+const closeTimeout = time.Second
+
+var upgrader = websocket.Upgrader{
+	// Mocks are called from anywhere, like the HTTP ones.
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// webSocketConversation holds a WebSocket connection, whose frames are written one at a time and
+// logged in the context of the history entry.
+type webSocketConversation struct {
+	conn    *websocket.Conn
+	context *types.Context
+	mu      sync.Mutex
+	done    chan struct{}
+	closed  bool
+	// wg tracks the goroutines sending the scripted frames, which may still log frames until
+	// they return.
+	wg sync.WaitGroup
+}
+
+// serveWebSocket upgrades the connection and runs the conversation of the mock until the
+// connection is closed.
+func serveWebSocket(c echo.Context, mock *types.MockWebSocket, context *types.Context) error {
+	conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// The upgrader already replied with an HTTP error.
+		slog.Warn("Failed to upgrade WebSocket connection", "error", err)
+		return nil
+	}
+	c.Response().Status = http.StatusSwitchingProtocols
+
+	conversation := &webSocketConversation{conn: conn, context: context, done: make(chan struct{})}
+	// The goroutines are stopped, and unblocked from their writes by closing the connection,
+	// before the history entry is recorded.
+	defer func() {
+		close(conversation.done)
+		conn.Close()
+		conversation.wg.Wait()
+	}()
+
+	conversation.goroutine(func() {
+		for _, frame := range mock.OnConnect {
+			if !conversation.wait(frame.Delay.Duration()) || conversation.send(frame) != nil {
+				return
+			}
+		}
+	})
+	for _, push := range mock.Pushes {
+		conversation.goroutine(func() {
+			for conversation.wait(push.Every.Duration()) && conversation.send(push.WebSocketFrame) == nil {
+			}
+		})
+	}
+	if mock.Close != nil {
+		conversation.goroutine(func() {
+			if conversation.wait(mock.Close.Delay.Duration()) {
+				conversation.close(*mock.Close)
+			}
+		})
+	}
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				conversation.log(types.WebSocketMessage{Direction: "in", Type: "close", Body: closeErr.Text, Code: closeErr.Code})
+			}
+			return nil
+		}
+
+		message := types.WebSocketMessage{Direction: "in", Type: "text"}
+		if messageType == websocket.BinaryMessage {
+			message.Type = "binary"
+		}
+		message.Body, message.BodyBase64 = types.BinarySafeBody(data)
+		conversation.log(message)
+
+		reply := mock.Reply(message.Body)
+		if reply == nil {
+			continue
+		}
+		for _, frame := range reply.Send {
+			if !conversation.wait(frame.Delay.Duration()) || conversation.send(frame) != nil {
+				return nil
+			}
+		}
+		if reply.Close != nil && conversation.wait(reply.Close.Delay.Duration()) {
+			conversation.close(*reply.Close)
+		}
+	}
+}
+
+// goroutine runs f in a goroutine the conversation waits for before it ends.
+func (wc *webSocketConversation) goroutine(f func()) {
+	wc.wg.Add(1)
+	go func() {
+		defer wc.wg.Done()
+		f()
+	}()
+}
+
+// wait waits for delay, and returns false when the conversation ended in the meantime.
+func (wc *webSocketConversation) wait(delay time.Duration) bool {
+	select {
+	case <-wc.done:
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
+
+func (wc *webSocketConversation) send(frame types.WebSocketFrame) error {
+	body, _ := frame.BodyBytes()
+	messageType, message := websocket.TextMessage, types.WebSocketMessage{Direction: "out", Type: "text", Body: frame.Body}
+	if frame.IsBinary() {
+		messageType, message = websocket.BinaryMessage, types.WebSocketMessage{Direction: "out", Type: "binary", Body: frame.BodyBase64, BodyBase64: true}
+	}
+
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	if wc.closed {
+		return websocket.ErrCloseSent
+	}
+	if err := wc.conn.WriteMessage(messageType, body); err != nil {
+		return err
+	}
+	wc.logLocked(message)
+	return nil
+}
+
+// close sends a close frame, and gives the client some time to acknowledge it before the
+// connection is dropped.
+func (wc *webSocketConversation) close(frame types.WebSocketClose) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	if wc.closed {
+		return
+	}
+	wc.closed = true
+	code := frame.StatusCode()
+	deadline := time.Now().Add(closeTimeout)
+	if err := wc.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, frame.Reason), deadline); err != nil {
+		slog.Warn("Failed to close WebSocket connection", "error", err)
+	}
+	_ = wc.conn.SetReadDeadline(deadline)
+	wc.logLocked(types.WebSocketMessage{Direction: "out", Type: "close", Body: frame.Reason, Code: code})
+}
+
+func (wc *webSocketConversation) log(message types.WebSocketMessage) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	wc.logLocked(message)
+}
+
+func (wc *webSocketConversation) logLocked(message types.WebSocketMessage) {
+	message.Date = time.Now()
+	wc.context.Frames = append(wc.context.Frames, message)
+}
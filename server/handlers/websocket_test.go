@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocket(t *testing.T) {
+	server, contexts := newMockServer(t, `
+request:
+  method: GET
+  path: /ws
+websocket:
+  on_connect:
+    - body: hello
+  on_message:
+    - body: {matcher: ShouldStartWith, value: ping}
+      send:
+        - body: pong
+        - body_base64: AAEC
+    - body: bye
+      close:
+        code: 4000
+        reason: see you
+`)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	read := func(expectedType int, expected string) {
+		t.Helper()
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if messageType != expectedType || string(data) != expected {
+			t.Errorf("frame = %d %q, want %d %q", messageType, data, expectedType, expected)
+		}
+	}
+	read(websocket.TextMessage, "hello")
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("ping 1")); err != nil {
+		t.Fatal(err)
+	}
+	read(websocket.TextMessage, "pong")
+	read(websocket.BinaryMessage, "\x00\x01\x02")
+	// Frames matching no reply are left unanswered.
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("unknown")); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("bye")); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != 4000 || closeErr.Text != "see you" {
+		t.Fatalf("expected a close frame, got %v", err)
+	}
+
+	context := <-contexts
+	if context == nil || context.MockType != "websocket" {
+		t.Fatalf("context = %+v", context)
+	}
+	var frames []string
+	for _, frame := range context.Frames {
+		frames = append(frames, strings.TrimSpace(frame.Direction+" "+frame.Type+" "+frame.Body))
+	}
+	expected := []string{
+		"out text hello",
+		"in text ping 1",
+		"out text pong",
+		"out binary AAEC",
+		"in text unknown",
+		"in text bye",
+		"out close see you",
+		"in close",
+	}
+	if strings.Join(frames, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("frames:\n%s\nwant:\n%s", strings.Join(frames, "\n"), strings.Join(expected, "\n"))
+	}
+}
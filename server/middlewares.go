@@ -3,7 +3,6 @@ package server
 import (
 	"bufio"
 	"bytes"
-	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -60,24 +59,22 @@ func HistoryMiddleware(s services.Mocks) echo.MiddlewareFunc {
 			}
 
 			responseBytes := responseBody.Bytes()
-			if c.Response().Header().Get("Content-Encoding") == "gzip" {
-				r, err := gzip.NewReader(responseBody)
+			if contentEncoding := c.Response().Header().Get(echo.HeaderContentEncoding); contentEncoding != "" && len(responseBytes) > 0 {
+				decoded, err := types.DecodeContentEncoding(responseBytes, contentEncoding)
 				if err != nil {
 					slog.Error("Unable to uncompress response body", "error", err)
 				} else {
-					responseBytes, err = io.ReadAll(r)
-					if err != nil {
-						slog.Error("Unable to read uncompressed response body", "error", err)
-						responseBytes = responseBody.Bytes()
-					}
+					responseBytes = decoded
 				}
 			}
 
 			var body interface{}
+			bodyString, bodyBase64 := types.BinarySafeBody(responseBytes)
 			if err := json.Unmarshal(responseBytes, &body); err != nil {
-				body = string(responseBytes)
+				body = bodyString
 			}
 
+			request.PathParams, _ = c.Get(types.PathParamsKey).(map[string]string)
 			context, _ := c.Get(types.ContextKey).(*types.Context)
 			if context == nil {
 				context = &types.Context{}
@@ -86,10 +83,11 @@ func HistoryMiddleware(s services.Mocks) echo.MiddlewareFunc {
 				Context: *context,
 				Request: request,
 				Response: types.Response{
-					Status:  c.Response().Status,
-					Body:    body,
-					Headers: c.Response().Header(),
-					Date:    time.Now(),
+					Status:     c.Response().Status,
+					Body:       body,
+					BodyBase64: bodyBase64,
+					Headers:    c.Response().Header(),
+					Date:       time.Now(),
 				},
 			})
 			if err != nil {
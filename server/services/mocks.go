@@ -23,6 +23,7 @@ type Mocks interface {
 	UpdateMock(sessionID, id string, mock *types.Mock) (*types.Mock, error)
 	DeleteMock(sessionID, id string) error
 	GetMocks(sessionID string) (types.Mocks, error)
+	GetCandidateMocks(sessionID string, req types.Request) (types.Mocks, error)
 	GetMockByID(sessionID, id string) (*types.Mock, error)
 	LockMocks(ids []string) types.Mocks
 	UnlockMocks(ids []string) types.Mocks
@@ -105,6 +106,7 @@ func (s *mocks) AddMock(sessionID string, newMock *types.Mock) (*types.Mock, err
 
 	newMock.Init()
 	session.Mocks = append(types.Mocks{newMock}, session.Mocks...)
+	session.ResetMockIndex()
 	go s.persistence.StoreMocks(session.ID, session.Mocks.Clone())
 	return newMock, nil
 }
@@ -133,6 +135,7 @@ func (s *mocks) UpdateMock(sessionID, id string, newMock *types.Mock) (*types.Mo
 				newMock.Context = &types.MockContext{}
 			}
 			*mock = *newMock
+			session.ResetMockIndex()
 			go s.persistence.StoreMocks(session.ID, session.Mocks.Clone())
 			return mock, nil
 		}
@@ -158,6 +161,7 @@ func (s *mocks) DeleteMock(sessionID, id string) error {
 	for i, mock := range session.Mocks {
 		if mock.State.ID == id {
 			session.Mocks = append(session.Mocks[:i], session.Mocks[i+1:]...)
+			session.ResetMockIndex()
 			go s.persistence.StoreMocks(session.ID, session.Mocks.Clone())
 			return nil
 		}
@@ -176,6 +180,19 @@ func (s *mocks) GetMocks(sessionID string) (types.Mocks, error) {
 	return session.Mocks.Clone(), nil
 }
 
+// GetCandidateMocks returns the mocks of the session which may match req, narrowed down with the
+// index of the session. Like GetMocks, the last declared mock comes first.
+func (s *mocks) GetCandidateMocks(sessionID string, req types.Request) (types.Mocks, error) {
+	session, err := s.GetSessionByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return session.MockIndex().Candidates(req), nil
+}
+
 func (s *mocks) LockMocks(ids []string) types.Mocks {
 	session := s.GetLastSession()
 	s.mu.Lock()
@@ -458,6 +475,7 @@ func (s *mocks) Reset(force bool) {
 		_ = s.GetLastSession()
 		s.mu.Lock()
 		s.sessions[len(s.sessions)-1].Mocks = mocks
+		s.sessions[len(s.sessions)-1].ResetMockIndex()
 		s.mu.Unlock()
 	}
 
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
@@ -10,7 +11,7 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-func newTestMocks(t *testing.T) Mocks {
+func newTestMocks(t testing.TB) Mocks {
 	t.Helper()
 	svc, err := NewMocks(nil, 0, NewPersistence(""), "")
 	if err != nil {
@@ -44,7 +45,7 @@ func TestGetLastSessionConcurrent(t *testing.T) {
 	}
 }
 
-func mockFromYAML(t *testing.T, s string) *types.Mock {
+func mockFromYAML(t testing.TB, s string) *types.Mock {
 	t.Helper()
 	var m types.Mock
 	if err := yaml.Unmarshal([]byte(s), &m); err != nil {
@@ -244,3 +245,83 @@ func TestEditForbiddenOnceCalled(t *testing.T) {
 		t.Errorf("UpdateMock after ClearHistory should succeed, got %v", err)
 	}
 }
+
+func addValidMock(t testing.TB, svc Mocks, sessionID, s string) *types.Mock {
+	t.Helper()
+	mock := mockFromYAML(t, s)
+	if err := mock.Validate(); err != nil {
+		t.Fatalf("invalid mock: %v", err)
+	}
+	added, err := svc.AddMock(sessionID, mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return added
+}
+
+// TestGetCandidateMocks checks that the index of a session follows the changes of its mocks.
+func TestGetCandidateMocks(t *testing.T) {
+	svc := newTestMocks(t)
+	session := svc.NewSession("index")
+	request := types.Request{Method: "GET", Path: "/users/42"}
+
+	users := addValidMock(t, svc, session.ID, "request: {method: GET, path: /users/42}\nresponse: {status: 200}")
+	addValidMock(t, svc, session.ID, "request: {method: POST, path: /users}\nresponse: {status: 201}")
+	if candidates, _ := svc.GetCandidateMocks(session.ID, request); len(candidates) != 1 || candidates[0] != users {
+		t.Fatalf("candidates = %+v", candidates)
+	}
+
+	// The last declared mock comes first.
+	prefixed := addValidMock(t, svc, session.ID, "request: {path: {matcher: ShouldStartWith, value: /users}}\nresponse: {status: 200}")
+	if candidates, _ := svc.GetCandidateMocks(session.ID, request); len(candidates) != 2 || candidates[0] != prefixed || candidates[1] != users {
+		t.Fatalf("candidates = %+v", candidates)
+	}
+
+	if _, err := svc.UpdateMock(session.ID, prefixed.State.ID, mockFromYAML(t, "request: {method: GET, path: /orders}\nresponse: {status: 200}")); err != nil {
+		t.Fatal(err)
+	}
+	if candidates, _ := svc.GetCandidateMocks(session.ID, request); len(candidates) != 1 || candidates[0] != users {
+		t.Fatalf("candidates after update = %+v", candidates)
+	}
+
+	if err := svc.DeleteMock(session.ID, users.State.ID); err != nil {
+		t.Fatal(err)
+	}
+	if candidates, _ := svc.GetCandidateMocks(session.ID, request); len(candidates) != 0 {
+		t.Fatalf("candidates after delete = %+v", candidates)
+	}
+}
+
+// BenchmarkMatchMocks compares matching a request against all the mocks of a large session with
+// matching it against the candidates of the session index.
+func BenchmarkMatchMocks(b *testing.B) {
+	svc := newTestMocks(b)
+	session := svc.NewSession("bench")
+	for i := 0; i < 5000; i++ {
+		addValidMock(b, svc, session.ID, fmt.Sprintf(`
+request:
+  method: %s
+  path: {matcher: ShouldMatch, value: "^/resources%d/[0-9]+$"}
+response:
+  status: 200
+`, []string{"GET", "POST", "PUT", "DELETE"}[i%4], i))
+	}
+	request := types.Request{Method: "GET", Path: "/resources2500/42"}
+
+	b.Run("all", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			mocks, _ := svc.GetMocks(session.ID)
+			if mock, _ := mocks.FirstMatch(request); mock == nil {
+				b.Fatal("no mock found")
+			}
+		}
+	})
+	b.Run("candidates", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			mocks, _ := svc.GetCandidateMocks(session.ID, request)
+			if mock, _ := mocks.FirstMatch(request); mock == nil {
+				b.Fatal("no mock found")
+			}
+		}
+	})
+}
@@ -1,9 +1,14 @@
 package templates
 
 import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/smocker-dev/smocker/server/types"
 )
 
@@ -39,3 +44,198 @@ func TestGoTemplateJsonDelayScalar(t *testing.T) {
 		t.Errorf("delay = {%v, %v}, want {5ms, 5ms}", res.Delay.Min, res.Delay.Max)
 	}
 }
+
+// TestLuaDelay covers the delays returned by Lua scripts, a single value being a fixed delay.
+func TestLuaDelay(t *testing.T) {
+	res, err := NewLuaEngine().Execute(types.Request{Path: "/test"},
+		`return { body = "hi", delay = "5ms", total_delay = 1000000000 }`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if res.Delay.Min != 5*time.Millisecond || res.Delay.Max != 5*time.Millisecond {
+		t.Errorf("delay = {%v, %v}, want {5ms, 5ms}", res.Delay.Min, res.Delay.Max)
+	}
+	if res.TotalDelay == nil || res.TotalDelay.Min != time.Second || res.TotalDelay.Max != time.Second {
+		t.Errorf("total delay = %+v, want {1s, 1s}", res.TotalDelay)
+	}
+
+	res, err = NewLuaEngine().Execute(types.Request{Path: "/test"},
+		`return { body = "hi", delay = { min = "1ms", max = "10ms" }, total_delay = { distribution = "normal", p50 = "1s", p99 = "2s" } }`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if res.Delay.Min != time.Millisecond || res.Delay.Max != 10*time.Millisecond {
+		t.Errorf("delay = {%v, %v}, want {1ms, 10ms}", res.Delay.Min, res.Delay.Max)
+	}
+	if res.TotalDelay == nil || res.TotalDelay.Distribution != types.NormalDelay || res.TotalDelay.P50 != time.Second || res.TotalDelay.P99 != 2*time.Second {
+		t.Errorf("total delay = %+v", res.TotalDelay)
+	}
+}
+
+func multipartRequest(t *testing.T) types.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.WriteField("title", "Q3 report"); err != nil {
+		t.Fatal(err)
+	}
+	part, err := w.CreateFormFile("document", "report.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write([]byte("%PDF-1.4")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return types.HTTPRequestToRequest(req)
+}
+
+// TestMultipartRequestData covers the parsed multipart form exposed to both template engines.
+func TestMultipartRequestData(t *testing.T) {
+	request := multipartRequest(t)
+
+	res, err := NewGoTemplateYamlEngine().Execute(request, `
+body: '{{ index .Request.Multipart.Fields.title 0 }} ({{ (index .Request.Multipart.Files.document 0).Size }} bytes)'
+`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if res.Body != "Q3 report (8 bytes)" {
+		t.Errorf("go template body = %q", res.Body)
+	}
+
+	res, err = NewLuaEngine().Execute(request, `
+local file = request.multipart.files.document[1]
+return { body = request.multipart.fields.title[1] .. " " .. file.filename }
+`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if res.Body != "Q3 report report.pdf" {
+		t.Errorf("lua body = %q", res.Body)
+	}
+}
+
+// TestPathParamsRequestData covers the parameters captured by the path matcher, exposed to both
+// template engines.
+func TestPathParamsRequestData(t *testing.T) {
+	matcher := types.StringMatcher{Matcher: types.PathTemplateMatcherName, Value: "/users/{userId}/orders/{orderId:[0-9]+}"}
+	request := types.HTTPRequestToRequest(httptest.NewRequest(http.MethodGet, "/users/u-1/orders/42", nil))
+	request.PathParams = matcher.Captures(request.Path)
+
+	res, err := NewGoTemplateYamlEngine().Execute(request, `
+body: '{{ .Request.PathParams.userId }}/{{ .Request.PathParams.orderId }}'
+`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if res.Body != "u-1/42" {
+		t.Errorf("go template body = %q", res.Body)
+	}
+
+	res, err = NewLuaEngine().Execute(request, `
+return { body = request.path_params.userId .. "/" .. request.path_params.orderId }
+`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if res.Body != "u-1/42" {
+		t.Errorf("lua body = %q", res.Body)
+	}
+}
+
+func TestJWTRequestData(t *testing.T) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":    "alice",
+		"tenant": map[string]interface{}{"id": "acme"},
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	request := types.HTTPRequestToRequest(req)
+
+	res, err := NewGoTemplateYamlEngine().Execute(request, `
+body: '{{ .Request.JWT.Claims.sub }}@{{ .Request.JWT.Claims.tenant.id }} ({{ .Request.JWT.Header.alg }})'
+`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if res.Body != "alice@acme (HS256)" {
+		t.Errorf("go template body = %q", res.Body)
+	}
+
+	res, err = NewLuaEngine().Execute(request, `
+return { body = request.jwt.claims.sub .. "@" .. request.jwt.claims.tenant.id }
+`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if res.Body != "alice@acme" {
+		t.Errorf("lua body = %q", res.Body)
+	}
+}
+
+func TestGraphQLRequestData(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewBufferString(`{"query": "query GetUser($id: ID!) { user(id: $id) { name } }", "variables": {"id": "42"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	request := types.HTTPRequestToRequest(req)
+
+	res, err := NewGoTemplateYamlEngine().Execute(request, `
+body: '{{ .Request.GraphQL.OperationName }} {{ index .Request.GraphQL.Fields 0 }} {{ .Request.GraphQL.Variables.id }}'
+`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if res.Body != "GetUser user 42" {
+		t.Errorf("go template body = %q", res.Body)
+	}
+
+	res, err = NewLuaEngine().Execute(request, `
+return { body = request.graphql.operation_type .. " " .. request.graphql.fields[1] .. " " .. request.graphql.variables.id }
+`)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if res.Body != "query user 42" {
+		t.Errorf("lua body = %q", res.Body)
+	}
+}
+
+// TestBinaryBody echoes a binary request body, which is exposed base64 encoded, in body_base64.
+func TestBinaryBody(t *testing.T) {
+	binary := []byte{0x89, 'P', 'N', 'G', 0xff, 0x00}
+	request := types.HTTPRequestToRequest(httptest.NewRequest(http.MethodPost, "/upload", bytes.NewReader(binary)))
+	if !request.BodyBase64 {
+		t.Fatal("expected the request body to be flagged as base64")
+	}
+
+	for name, execute := range map[string]func() (*types.MockResponse, error){
+		"go template yaml": func() (*types.MockResponse, error) {
+			return NewGoTemplateYamlEngine().Execute(request, `body_base64: '{{ .Request.BodyString }}'`)
+		},
+		"go template json": func() (*types.MockResponse, error) {
+			return NewGoTemplateJsonEngine().Execute(request, `{"body_base64": "{{ .Request.BodyString }}"}`)
+		},
+		"lua": func() (*types.MockResponse, error) {
+			return NewLuaEngine().Execute(request, `return { body_base64 = request.body_string }`)
+		},
+	} {
+		res, err := execute()
+		if err != nil {
+			t.Fatalf("%s: Execute: %v", name, err)
+		}
+		body, err := res.BodyBytes()
+		if err != nil {
+			t.Fatalf("%s: BodyBytes: %v", name, err)
+		}
+		if !bytes.Equal(body, binary) {
+			t.Errorf("%s: body = %v", name, body)
+		}
+	}
+}
@@ -70,12 +70,23 @@ func (*luaEngine) Execute(request types.Request, script string) (*types.MockResp
 		luaResult.RawSetString("body", lua.LString(string(b)))
 	}
 
-	delay := &lua.LTable{}
-	if err := parseLuaDelay(luaResult, "delay", delay, "value"); err != nil {
-		slog.Error("Invalid delay from lua script", "error", err)
-		return nil, fmt.Errorf("invalid delay from Lua script: %w", err)
+	for _, key := range []string{"delay", "total_delay"} {
+		if luaResult.RawGetString(key) == lua.LNil {
+			continue
+		}
+		delay := &lua.LTable{}
+		if err := parseLuaDelay(luaResult, key, delay, "value"); err != nil {
+			slog.Error("Invalid delay from lua script", "key", key, "error", err)
+			return nil, fmt.Errorf("invalid %s from Lua script: %w", key, err)
+		}
+		if value := delay.RawGetString("value"); value != lua.LNil {
+			// A single value is a fixed delay, like the scalar form of JSON and YAML delays.
+			delay.RawSetString("min", value)
+			delay.RawSetString("max", value)
+			delay.RawSetString("value", lua.LNil)
+		}
+		luaResult.RawSetString(key, delay)
 	}
-	luaResult.RawSetString("delay", delay)
 
 	var result types.MockResponse
 	if err := gluamapper.Map(luaResult, &result); err != nil {
@@ -101,14 +112,13 @@ func parseLuaDelay(value *lua.LTable, valueKey string, res *lua.LTable, resKey s
 		res.RawSetString(resKey, lua.LNumber(float64(delay)))
 	case lua.LTTable:
 		table := d.(*lua.LTable)
-		if err := parseLuaDelay(table, "value", res, "value"); err != nil {
-			return err
+		for _, key := range []string{"value", "min", "max", "mean", "stddev", "p50", "p99"} {
+			if err := parseLuaDelay(table, key, res, key); err != nil {
+				return err
+			}
 		}
-		if err := parseLuaDelay(table, "min", res, "min"); err != nil {
-			return err
-		}
-		if err := parseLuaDelay(table, "max", res, "max"); err != nil {
-			return err
+		if distribution := table.RawGetString("distribution"); distribution != lua.LNil {
+			res.RawSetString("distribution", distribution)
 		}
 	default:
 		return fmt.Errorf("invalid lua type for key %q: %s", valueKey, d.Type().String())
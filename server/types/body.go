@@ -0,0 +1,15 @@
+package types
+
+import (
+	"encoding/base64"
+	"unicode/utf8"
+)
+
+// BinarySafeBody returns body as text, or base64 encoded when it isn't valid UTF-8, so that
+// binary payloads survive the YAML and JSON serialization of mocks and history.
+func BinarySafeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestBinarySafeBody(t *testing.T) {
+	text := HTTPRequestToRequest(httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("héllo")))
+	if text.BodyBase64 || text.BodyString != "héllo" || string(text.RawBody()) != "héllo" {
+		t.Errorf("text body = %q (base64: %v)", text.BodyString, text.BodyBase64)
+	}
+
+	binary := []byte{0x89, 'P', 'N', 'G', 0xff, 0x00}
+	req := HTTPRequestToRequest(httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(binary)))
+	if !req.BodyBase64 || req.BodyString != "iVBOR/8A" || req.Body != "iVBOR/8A" {
+		t.Errorf("binary body = %q (base64: %v)", req.BodyString, req.BodyBase64)
+	}
+	if !bytes.Equal(req.RawBody(), binary) {
+		t.Errorf("raw body = %v", req.RawBody())
+	}
+}
+
+// TestBinaryMultipartBody checks that the fields of a multipart form holding a binary file are
+// matched, though the body is recorded base64 encoded.
+func TestBinaryMultipartBody(t *testing.T) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.WriteField("title", "doc"); err != nil {
+		t.Fatal(err)
+	}
+	part, err := w.CreateFormFile("document", "a.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write([]byte{'%', 'P', 'D', 'F', 0xff, 0xfe, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	httpReq := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+	httpReq.Header.Set("Content-Type", w.FormDataContentType())
+	req := HTTPRequestToRequest(httpReq)
+	if !req.BodyBase64 {
+		t.Fatalf("body = %q, expected it to be recorded base64 encoded", req.BodyString)
+	}
+
+	for _, y := range []string{
+		`{request: {body: {"fields.title[0]": doc}}, response: {status: 200}}`,
+		`{request: {body: {json_path: {"$.files.document[0].filename": a.pdf}}}, response: {status: 200}}`,
+	} {
+		var mock Mock
+		if err := yaml.Unmarshal([]byte(y), &mock); err != nil {
+			t.Fatal(err)
+		}
+		if err := mock.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if !mock.Request.Match(req) {
+			t.Errorf("%s should match the multipart body, got %+v", y, mock.Request.Check(req))
+		}
+	}
+}
+
+func TestMockResponseBodyBase64(t *testing.T) {
+	body, err := MockResponse{BodyBase64: "iVBOR/8A"}.BodyBytes()
+	if err != nil || !bytes.Equal(body, []byte{0x89, 'P', 'N', 'G', 0xff, 0x00}) {
+		t.Errorf("body = %v, err = %v", body, err)
+	}
+	if body, _ := (MockResponse{Body: "text"}).BodyBytes(); string(body) != "text" {
+		t.Errorf("body = %q", body)
+	}
+
+	for _, response := range []MockResponse{
+		{Body: "text", BodyBase64: "dGV4dA=="},
+		{BodyBase64: "not base64!"},
+		{Body: "text", Chunks: []ResponseChunk{{Body: "chunk"}}},
+		{Chunks: []ResponseChunk{{BodyBase64: "not base64!"}}},
+	} {
+		if err := response.Validate(); err == nil {
+			t.Errorf("expected an error validating %+v", response)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package types
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// contentCodings lists the codings of a Content-Encoding header, in the order they were applied,
+// leaving out identity.
+func contentCodings(contentEncoding string) []string {
+	codings := []string{}
+	for _, coding := range strings.Split(contentEncoding, ",") {
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "" && coding != "identity" {
+			codings = append(codings, coding)
+		}
+	}
+	return codings
+}
+
+// DecodeContentEncoding decodes a body compressed with the codings of a Content-Encoding header:
+// gzip, deflate, br and zstd.
+func DecodeContentEncoding(body []byte, contentEncoding string) ([]byte, error) {
+	codings := contentCodings(contentEncoding)
+	for i := len(codings) - 1; i >= 0; i-- {
+		decoded, err := decodeContent(codings[i], body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode %s content: %w", codings[i], err)
+		}
+		body = decoded
+	}
+	return body, nil
+}
+
+// maxDecodedContentSize caps the size of a decoded body, so that a small compressed body cannot
+// expand into an exhausting one.
+var maxDecodedContentSize int64 = 64 << 20
+
+func decodeContent(coding string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	switch coding {
+	case "gzip", "x-gzip":
+		gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		reader = gzipReader
+	case "deflate":
+		// deflate is a zlib stream (RFC 9110), but some clients send a raw deflate stream.
+		if zlibReader, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+			reader = zlibReader
+		} else {
+			reader = flate.NewReader(bytes.NewReader(body))
+		}
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case "zstd":
+		decoder, err := zstd.NewReader(bytes.NewReader(body), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		reader = decoder
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", coding)
+	}
+	decoded, err := io.ReadAll(io.LimitReader(reader, maxDecodedContentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(decoded)) > maxDecodedContentSize {
+		return nil, fmt.Errorf("decoded content exceeds %d bytes", maxDecodedContentSize)
+	}
+	return decoded, nil
+}
+
+// Compression is the content coding a response body is compressed with: gzip, deflate, br, or
+// CompressAuto to negotiate it from the Accept-Encoding header of the request.
+type Compression string
+
+const (
+	CompressAuto Compression = "auto"
+)
+
+// Compressions lists the codings responses can be compressed with, in order of preference.
+var Compressions = []Compression{"br", "gzip", "deflate"}
+
+func (c Compression) IsValid() bool {
+	return c == CompressAuto || slices.Contains(Compressions, c)
+}
+
+// Negotiate returns the coding to compress a response with, or an empty string when the client
+// accepts none of the supported ones.
+func (c Compression) Negotiate(acceptEncoding string) string {
+	if c != CompressAuto {
+		return string(c)
+	}
+
+	qualities := map[string]float64{}
+	for _, entry := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(entry, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		quality := 1.0
+		if name, value, found := strings.Cut(strings.TrimSpace(params), "="); found && strings.TrimSpace(name) == "q" {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				quality = q
+			}
+		}
+		qualities[coding] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, compression := range Compressions {
+		quality, found := qualities[string(compression)]
+		if !found {
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = string(compression), quality
+		}
+	}
+	return best
+}
+
+// EncodeContent compresses a body with a content coding: gzip, deflate or br.
+func EncodeContent(body []byte, coding string) ([]byte, error) {
+	var (
+		buf    bytes.Buffer
+		writer io.WriteCloser
+	)
+	switch coding {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "deflate":
+		writer = zlib.NewWriter(&buf)
+	case "br":
+		writer = brotli.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", coding)
+	}
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
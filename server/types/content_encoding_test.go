@@ -0,0 +1,172 @@
+package types
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"gopkg.in/yaml.v3"
+)
+
+func encodeContent(t *testing.T, coding string, body []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	switch coding {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "deflate":
+		writer = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		writer, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		writer = brotli.NewWriter(&buf)
+	case "zstd":
+		var err error
+		if writer, err = zstd.NewWriter(&buf); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		t.Fatalf("unknown coding %q", coding)
+	}
+	if _, err := writer.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeContentEncoding(t *testing.T) {
+	body := []byte(`{"name": "smocker"}`)
+	tests := []struct {
+		header  string
+		encoded []byte
+	}{
+		{"", body},
+		{"identity", body},
+		{"gzip", encodeContent(t, "gzip", body)},
+		{"x-gzip", encodeContent(t, "gzip", body)},
+		{"deflate", encodeContent(t, "deflate", body)},
+		{"deflate", encodeContent(t, "raw-deflate", body)},
+		{"br", encodeContent(t, "br", body)},
+		{"zstd", encodeContent(t, "zstd", body)},
+		{"gzip, BR", encodeContent(t, "br", encodeContent(t, "gzip", body))},
+	}
+	for _, test := range tests {
+		decoded, err := DecodeContentEncoding(test.encoded, test.header)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.header, err)
+		} else if !bytes.Equal(decoded, body) {
+			t.Errorf("%q: decoded = %q", test.header, decoded)
+		}
+	}
+
+	if _, err := DecodeContentEncoding(body, "gzip"); err == nil {
+		t.Error("expected an error decoding an invalid gzip body")
+	}
+	if _, err := DecodeContentEncoding(body, "compress"); err == nil {
+		t.Error("expected an error decoding an unsupported coding")
+	}
+
+	// Bodies expanding beyond the cap are rejected rather than decoded.
+	defer func(max int64) { maxDecodedContentSize = max }(maxDecodedContentSize)
+	maxDecodedContentSize = 1024
+	for _, coding := range []string{"gzip", "deflate", "br", "zstd"} {
+		if _, err := DecodeContentEncoding(encodeContent(t, coding, make([]byte, 1024)), coding); err != nil {
+			t.Errorf("%s: unexpected error decoding a body of the size of the cap: %v", coding, err)
+		}
+		if _, err := DecodeContentEncoding(encodeContent(t, coding, make([]byte, 1025)), coding); err == nil {
+			t.Errorf("%s: expected an error decoding a body exceeding the cap", coding)
+		}
+	}
+}
+
+func TestCompressedRequestBody(t *testing.T) {
+	var mock Mock
+	err := yaml.Unmarshal([]byte(`
+request:
+  method: POST
+  path: /users
+  body:
+    name: smocker
+response:
+  status: 200
+`), &mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded := encodeContent(t, "gzip", []byte(`{"name": "smocker"}`))
+	httpReq := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(encoded))
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Content-Encoding", "gzip")
+	req := HTTPRequestToRequest(httpReq)
+	if req.ContentEncoding != "gzip" || req.BodyString != `{"name": "smocker"}` {
+		t.Errorf("content_encoding = %q, body_string = %q", req.ContentEncoding, req.BodyString)
+	}
+	if !mock.Request.Match(req) {
+		t.Error("expected the decoded body to match")
+	}
+	// The request body is left as received.
+	if raw, _ := io.ReadAll(httpReq.Body); !bytes.Equal(raw, encoded) {
+		t.Error("expected the request body to be left encoded")
+	}
+
+	// Bodies which can't be decoded are kept as is.
+	httpReq = httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader([]byte("plain")))
+	httpReq.Header.Set("Content-Encoding", "gzip")
+	req = HTTPRequestToRequest(httpReq)
+	if req.ContentEncoding != "" || req.BodyString != "plain" {
+		t.Errorf("content_encoding = %q, body_string = %q", req.ContentEncoding, req.BodyString)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	body := []byte(`{"name": "smocker"}`)
+	for _, compression := range Compressions {
+		encoded, err := EncodeContent(body, string(compression))
+		if err != nil {
+			t.Fatalf("%s: %v", compression, err)
+		}
+		if decoded, err := DecodeContentEncoding(encoded, string(compression)); err != nil || !bytes.Equal(decoded, body) {
+			t.Errorf("%s: decoded = %q, %v", compression, decoded, err)
+		}
+	}
+
+	tests := map[string]string{
+		"":                              "",
+		"identity":                      "",
+		"gzip":                          "gzip",
+		"gzip, deflate, br":             "br",
+		"gzip;q=1.0, br;q=0.5":          "gzip",
+		"deflate, *;q=0.1":              "deflate",
+		"*":                             "br",
+		"*, br;q=0":                     "gzip",
+		"compress, GZIP ; q=0.8":        "gzip",
+		"br;q=0, gzip;q=0, deflate;q=0": "",
+	}
+	for acceptEncoding, expected := range tests {
+		if coding := CompressAuto.Negotiate(acceptEncoding); coding != expected {
+			t.Errorf("%q: negotiated %q, expected %q", acceptEncoding, coding, expected)
+		}
+	}
+	if coding := Compression("deflate").Negotiate("br"); coding != "deflate" {
+		t.Errorf("negotiated %q, expected a fixed compression to ignore Accept-Encoding", coding)
+	}
+
+	if err := (&MockResponse{Body: "hello", Compress: "zstd"}).Validate(); err == nil {
+		t.Error("expected an unsupported compression to be rejected")
+	}
+	if err := (&MockResponse{Chunks: []ResponseChunk{{Body: "hello"}}, Compress: CompressAuto}).Validate(); err == nil {
+		t.Error("expected a compression of chunks to be rejected")
+	}
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Cookies returns the cookies sent with the request, parsed from its Cookie headers. A name may be
+// sent several times, e.g. by cookies set for different paths.
+func (r Request) Cookies() map[string][]string {
+	cookies := map[string][]string{}
+	for _, cookie := range (&http.Request{Header: r.Headers}).Cookies() {
+		cookies[cookie.Name] = append(cookies[cookie.Name], cookie.Value)
+	}
+	return cookies
+}
+
+// MockCookie is a cookie set by a mock response, rendered as a Set-Cookie header. A negative MaxAge
+// deletes the cookie (Max-Age=0).
+type MockCookie struct {
+	Name     string     `json:"name" yaml:"name"`
+	Value    string     `json:"value" yaml:"value"`
+	Path     string     `json:"path,omitempty" yaml:"path,omitempty"`
+	Domain   string     `json:"domain,omitempty" yaml:"domain,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty" yaml:"expires,omitempty"`
+	MaxAge   int        `json:"max_age,omitempty" yaml:"max_age,omitempty"`
+	Secure   bool       `json:"secure,omitempty" yaml:"secure,omitempty"`
+	HttpOnly bool       `json:"http_only,omitempty" yaml:"http_only,omitempty"`
+	SameSite string     `json:"same_site,omitempty" yaml:"same_site,omitempty"`
+}
+
+var sameSiteModes = map[string]http.SameSite{
+	"":       http.SameSiteDefaultMode,
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+func (mc MockCookie) Validate() error {
+	if _, ok := sameSiteModes[strings.ToLower(mc.SameSite)]; !ok {
+		return fmt.Errorf("invalid same_site %q for cookie %q, expected Lax, Strict or None", mc.SameSite, mc.Name)
+	}
+	if err := mc.HTTPCookie().Valid(); err != nil {
+		return fmt.Errorf("invalid cookie %q: %v", mc.Name, err)
+	}
+	return nil
+}
+
+func (mc MockCookie) HTTPCookie() *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     mc.Name,
+		Value:    mc.Value,
+		Path:     mc.Path,
+		Domain:   mc.Domain,
+		MaxAge:   mc.MaxAge,
+		Secure:   mc.Secure,
+		HttpOnly: mc.HttpOnly,
+		SameSite: sameSiteModes[strings.ToLower(mc.SameSite)],
+	}
+	if mc.Expires != nil {
+		cookie.Expires = *mc.Expires
+	}
+	return cookie
+}
+
+type MockCookies []MockCookie
+
+func (mcs MockCookies) Validate() error {
+	for _, cookie := range mcs {
+		if err := cookie.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetCookieHeaders returns the values of the Set-Cookie headers setting the cookies. Invalid
+// cookies, which Validate reports for static responses, are left out.
+func (mcs MockCookies) SetCookieHeaders() []string {
+	headers := make([]string, 0, len(mcs))
+	for _, cookie := range mcs {
+		if header := cookie.HTTPCookie().String(); header != "" {
+			headers = append(headers, header)
+		}
+	}
+	return headers
+}
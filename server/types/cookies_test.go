@@ -0,0 +1,94 @@
+package types
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCookiesMatcher(t *testing.T) {
+	var mock Mock
+	err := yaml.Unmarshal([]byte(`
+request:
+  path: /profile
+  cookies:
+    session: {matcher: ShouldMatch, value: "^[a-f0-9]+$"}
+    theme: {any_of: [dark, light]}
+response:
+  status: 200
+`), &mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(cookies ...string) Request {
+		return Request{Method: http.MethodGet, Path: "/profile", Headers: http.Header{"Cookie": cookies}}
+	}
+	if got := request("session=abc123; theme=dark", "lang=fr").Cookies(); !reflect.DeepEqual(got, map[string][]string{
+		"session": {"abc123"},
+		"theme":   {"dark"},
+		"lang":    {"fr"},
+	}) {
+		t.Errorf("cookies = %v", got)
+	}
+
+	if !mock.Request.Match(request("session=abc123; theme=dark; lang=fr")) {
+		t.Error("request with matching cookies should match")
+	}
+	if mock.Request.Match(request("session=abc123; theme=blue")) {
+		t.Error("request with a mismatching cookie should not match")
+	}
+	if mock.Request.Match(request("theme=dark")) {
+		t.Error("request with a missing cookie should not match")
+	}
+	if mock.Request.Match(request()) {
+		t.Error("request without cookies should not match")
+	}
+}
+
+func TestMockResponseCookies(t *testing.T) {
+	var response MockResponse
+	err := yaml.Unmarshal([]byte(`
+status: 200
+cookies:
+  - name: session
+    value: abc123
+    path: /
+    domain: example.com
+    expires: 2030-01-02T15:04:05Z
+    http_only: true
+    secure: true
+    same_site: Strict
+  - name: legacy
+    value: ""
+    max_age: -1
+`), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := response.Cookies.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"session=abc123; Path=/; Domain=example.com; Expires=Wed, 02 Jan 2030 15:04:05 GMT; HttpOnly; Secure; SameSite=Strict",
+		"legacy=; Max-Age=0",
+	}
+	if got := response.Cookies.SetCookieHeaders(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Set-Cookie headers = %q, want %q", got, want)
+	}
+
+	for _, invalid := range []MockCookie{
+		{Name: "session", Value: "x", SameSite: "sometimes"},
+		{Name: "bad name", Value: "x"},
+	} {
+		if err := (MockCookies{invalid}).Validate(); err == nil {
+			t.Errorf("%+v should be rejected", invalid)
+		}
+	}
+}
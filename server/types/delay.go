@@ -0,0 +1,76 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+type DelayDistribution string
+
+const (
+	UniformDelay   DelayDistribution = "uniform"
+	NormalDelay    DelayDistribution = "normal"
+	LogNormalDelay DelayDistribution = "lognormal"
+)
+
+// z99 is the 99th percentile of the standard normal distribution.
+const z99 = 2.3263478740408408
+
+// sample picks a delay following the normal or log-normal distribution of the delay, bounded by
+// its Min and, when set, its Max.
+func (d Delay) sample() time.Duration {
+	var v float64
+	switch d.Distribution {
+	case NormalDelay:
+		mean, stddev := float64(d.Mean), float64(d.StdDev)
+		if d.P50 != 0 {
+			mean, stddev = float64(d.P50), float64(d.P99-d.P50)/z99
+		}
+		v = mean + stddev*rand.NormFloat64()
+	case LogNormalDelay:
+		var mu, sigma float64
+		if d.P50 != 0 {
+			mu, sigma = math.Log(float64(d.P50)), math.Log(float64(d.P99)/float64(d.P50))/z99
+		} else {
+			mean, stddev := float64(d.Mean), float64(d.StdDev)
+			variance := math.Log(1 + stddev*stddev/(mean*mean))
+			mu, sigma = math.Log(mean)-variance/2, math.Sqrt(variance)
+		}
+		v = math.Exp(mu + sigma*rand.NormFloat64())
+	}
+
+	delay := time.Duration(v)
+	if delay < d.Min {
+		delay = d.Min
+	}
+	if d.Max > 0 && delay > d.Max {
+		delay = d.Max
+	}
+	return delay
+}
+
+func (d Delay) validateDistribution() error {
+	if d.Min < 0 || (d.Max > 0 && d.Max < d.Min) {
+		return fmt.Errorf("invalid delay bounds: min => %v, max => %v", d.Min, d.Max)
+	}
+	percentiles := d.P50 != 0 || d.P99 != 0
+	moments := d.Mean != 0 || d.StdDev != 0
+	switch {
+	case percentiles && moments:
+		return errors.New("a delay distribution must be defined either by its mean and stddev, or by its p50 and p99, not both of them")
+	case percentiles:
+		if d.P50 <= 0 || d.P99 < d.P50 {
+			return fmt.Errorf("invalid delay percentiles: p50 => %v, p99 => %v", d.P50, d.P99)
+		}
+	case moments:
+		if d.StdDev < 0 || (d.Distribution == LogNormalDelay && d.Mean <= 0) {
+			return fmt.Errorf("invalid delay distribution: mean => %v, stddev => %v", d.Mean, d.StdDev)
+		}
+	default:
+		return fmt.Errorf("a %s delay must define its mean and stddev, or its p50 and p99", d.Distribution)
+	}
+	return nil
+}
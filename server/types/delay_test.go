@@ -0,0 +1,104 @@
+package types
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDelayDistributions(t *testing.T) {
+	doc := `
+request:
+  path: /slow
+response:
+  status: 200
+  body: hello
+  delay:
+    distribution: lognormal
+    p50: 20ms
+    p99: 200ms
+    max: 1s
+  total_delay: 500ms
+  bandwidth: 1024
+`
+	if err := compileMockSchema(t).Validate(asJSONValue(t, []byte(doc))); err != nil {
+		t.Error(err)
+	}
+	var mock Mock
+	if err := yaml.Unmarshal([]byte(doc), &mock); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if mock.Response.TotalDelay.Min != 500*time.Millisecond || mock.Response.Bandwidth != 1024 {
+		t.Errorf("response = %+v, expected its total delay and bandwidth to be read", mock.Response)
+	}
+
+	for name, delay := range map[string]Delay{
+		"normal from mean":       {Distribution: NormalDelay, Mean: 100 * time.Millisecond, StdDev: 10 * time.Millisecond},
+		"normal from p50":        {Distribution: NormalDelay, P50: 100 * time.Millisecond, P99: 123 * time.Millisecond},
+		"lognormal from p50":     {Distribution: LogNormalDelay, P50: 100 * time.Millisecond, P99: 300 * time.Millisecond},
+		"lognormal from mean":    {Distribution: LogNormalDelay, Mean: 100 * time.Millisecond, StdDev: 10 * time.Millisecond},
+		"bounded lognormal mean": {Distribution: LogNormalDelay, Mean: 100 * time.Millisecond, StdDev: 10 * time.Millisecond, Min: 95 * time.Millisecond, Max: 105 * time.Millisecond},
+	} {
+		if err := delay.validate(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		samples := make([]time.Duration, 10000)
+		for i := range samples {
+			samples[i] = delay.Duration()
+		}
+		slices.Sort(samples)
+		if p50 := samples[len(samples)/2]; p50 < 90*time.Millisecond || p50 > 110*time.Millisecond {
+			t.Errorf("%s: p50 = %v, expected about 100ms", name, p50)
+		}
+		if delay.Min > 0 && (samples[0] < delay.Min || samples[len(samples)-1] > delay.Max) {
+			t.Errorf("%s: samples between %v and %v, expected them within the bounds", name, samples[0], samples[len(samples)-1])
+		}
+		if delay.P99 > 0 {
+			if p99 := samples[len(samples)*99/100]; p99 < delay.P99*9/10 || p99 > delay.P99*11/10 {
+				t.Errorf("%s: p99 = %v, expected about %v", name, p99, delay.P99)
+			}
+		}
+	}
+
+	var delay Delay
+	if err := json.Unmarshal([]byte(`{"distribution": "normal", "mean": "1s", "stddev": 100000000}`), &delay); err != nil {
+		t.Fatal(err)
+	}
+	if delay != (Delay{Distribution: NormalDelay, Mean: time.Second, StdDev: 100 * time.Millisecond}) {
+		t.Errorf("delay = %+v, expected its distribution to be read from JSON", delay)
+	}
+
+	for name, delay := range map[string]Delay{
+		"unknown distribution":     {Distribution: "pareto", Mean: time.Second},
+		"missing parameters":       {Distribution: NormalDelay},
+		"mean and percentiles":     {Distribution: NormalDelay, Mean: time.Second, P50: time.Second, P99: 2 * time.Second},
+		"p99 below p50":            {Distribution: LogNormalDelay, P50: time.Second, P99: time.Millisecond},
+		"lognormal without mean":   {Distribution: LogNormalDelay, StdDev: time.Second},
+		"uniform with percentiles": {Min: time.Second, Max: time.Second, P50: time.Second},
+	} {
+		if err := delay.validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := (&MockResponse{Bandwidth: -1}).Validate(); err == nil {
+		t.Error("expected a negative bandwidth to be rejected")
+	}
+	for name, response := range map[string]MockResponse{
+		"reversed delay":          {Delay: Delay{Min: time.Second, Max: time.Millisecond}},
+		"lognormal total delay":   {TotalDelay: &Delay{Distribution: LogNormalDelay}},
+		"reversed chunk delay":    {Chunks: []ResponseChunk{{Body: "a", Delay: Delay{Min: time.Second}}}},
+		"throttled chunks":        {Chunks: []ResponseChunk{{Body: "a"}}, Bandwidth: 10},
+		"total delay of sse":      {SSE: &SSEResponse{Events: []SSEEvent{{Data: "a"}}}, TotalDelay: &Delay{Min: time.Second, Max: time.Second}},
+		"normal sse without mean": {SSE: &SSEResponse{Events: []SSEEvent{{Data: "a", Delay: Delay{Distribution: NormalDelay}}}}},
+	} {
+		if err := response.Validate(); err == nil {
+			t.Errorf("%s: expected the response to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+)
+
+type FaultType string
+
+const (
+	// FaultConnectionReset resets the TCP connection without responding.
+	FaultConnectionReset FaultType = "connection_reset"
+	// FaultEmptyResponse closes the connection without responding.
+	FaultEmptyResponse FaultType = "empty_response"
+	// FaultHangAfterHeaders sends the status and headers, then neither the body nor the end of
+	// the response, until the client disconnects or the duration of the fault elapses.
+	FaultHangAfterHeaders FaultType = "hang_after_headers"
+	// FaultTruncatedBody declares the full body in Content-Length but sends only half of it.
+	FaultTruncatedBody FaultType = "truncated_body"
+	// FaultMalformedResponse answers with bytes which are not valid HTTP.
+	FaultMalformedResponse FaultType = "malformed_response"
+)
+
+var FaultTypes = []FaultType{
+	FaultConnectionReset,
+	FaultEmptyResponse,
+	FaultHangAfterHeaders,
+	FaultTruncatedBody,
+	FaultMalformedResponse,
+}
+
+// MockFault is a network fault a mock answers with instead of its response, with a probability
+// between 0 and 1 (1 when not set).
+type MockFault struct {
+	Type        FaultType `json:"type" yaml:"type"`
+	Probability float64   `json:"probability,omitempty" yaml:"probability,omitempty"`
+	Duration    Delay     `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+// MockFaults are the faults of a mock, at most one of which is picked for each call, according to
+// their probabilities.
+type MockFaults []MockFault
+
+func (mf MockFault) probability() float64 {
+	if mf.Probability == 0 {
+		return 1
+	}
+	return mf.Probability
+}
+
+func (mfs MockFaults) Validate() error {
+	total := 0.0
+	for _, fault := range mfs {
+		valid := false
+		for _, faultType := range FaultTypes {
+			valid = valid || fault.Type == faultType
+		}
+		if !valid {
+			return fmt.Errorf("The fault type must be one of the following: %v", FaultTypes)
+		}
+		if fault.Probability < 0 || fault.Probability > 1 {
+			return fmt.Errorf("The probability of fault %s must be between 0 and 1", fault.Type)
+		}
+		if fault.Duration != (Delay{}) && fault.Type != FaultHangAfterHeaders {
+			return fmt.Errorf("The duration of fault %s is only used by %s faults", fault.Type, FaultHangAfterHeaders)
+		}
+		total += fault.probability()
+	}
+	if total > 1 {
+		return fmt.Errorf("The sum of the fault probabilities must not exceed 1 (but was %v)", total)
+	}
+	return nil
+}
+
+// Pick returns the fault to inject for a call, nil when the call must be answered normally.
+func (mfs MockFaults) Pick() *MockFault {
+	n := rand.Float64()
+	for i, fault := range mfs {
+		if n -= fault.probability(); n < 0 {
+			return &mfs[i]
+		}
+	}
+	return nil
+}
+
+func (mfs *MockFaults) UnmarshalJSON(data []byte) error {
+	var fault MockFault
+	if err := json.Unmarshal(data, &fault); err == nil {
+		*mfs = MockFaults{fault}
+		return nil
+	}
+
+	var res []MockFault
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+	*mfs = res
+	return nil
+}
+
+func (mfs *MockFaults) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var fault MockFault
+	if err := unmarshal(&fault); err == nil {
+		*mfs = MockFaults{fault}
+		return nil
+	}
+
+	var res []MockFault
+	if err := unmarshal(&res); err != nil {
+		return err
+	}
+	*mfs = res
+	return nil
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMockFaults(t *testing.T) {
+	doc := `
+request:
+  path: /flaky
+response:
+  status: 200
+fault:
+  type: connection_reset
+`
+	if err := compileMockSchema(t).Validate(asJSONValue(t, []byte(doc))); err != nil {
+		t.Error(err)
+	}
+	var mock Mock
+	if err := yaml.Unmarshal([]byte(doc), &mock); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if fault := mock.Fault.Pick(); fault == nil || fault.Type != FaultConnectionReset {
+		t.Errorf("fault = %+v, expected a fault without probability to always be picked", fault)
+	}
+
+	faults := MockFaults{{Type: FaultTruncatedBody, Probability: 0.2}, {Type: FaultEmptyResponse, Probability: 0.3}}
+	if err := faults.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	counts := map[FaultType]int{}
+	for i := 0; i < 10000; i++ {
+		if fault := faults.Pick(); fault != nil {
+			counts[fault.Type]++
+		} else {
+			counts[""]++
+		}
+	}
+	if counts[FaultTruncatedBody] < 1500 || counts[FaultEmptyResponse] < 2500 || counts[""] < 4500 {
+		t.Errorf("picked faults = %v, expected them to follow the probabilities", counts)
+	}
+
+	for name, faults := range map[string]MockFaults{
+		"unknown type":         {{Type: "timeout"}},
+		"invalid probability":  {{Type: FaultEmptyResponse, Probability: 1.5}},
+		"probabilities over 1": {{Type: FaultEmptyResponse, Probability: 0.6}, {Type: FaultConnectionReset, Probability: 0.6}},
+		"unused duration":      {{Type: FaultEmptyResponse, Duration: Delay{Min: 1, Max: 1}}},
+	} {
+		if err := faults.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
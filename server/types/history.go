@@ -2,9 +2,11 @@ package types
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"io"
 	"log/slog"
+	"mime"
 	"net"
 	"net/http"
 	"net/url"
@@ -12,7 +14,10 @@ import (
 	"time"
 )
 
-const ContextKey = "Context"
+const (
+	ContextKey    = "Context"
+	PathParamsKey = "PathParams"
+)
 
 type History []*Entry
 
@@ -30,24 +35,54 @@ type Context struct {
 	MockID   string `json:"mock_id,omitempty"`
 	MockType string `json:"mock_type,omitempty"`
 	Delay    string `json:"delay,omitempty"`
+	// TotalDelay is the total time picked for the response, counted from the reception of the request.
+	TotalDelay string `json:"total_delay,omitempty" yaml:"total_delay,omitempty"`
+	// Fault is the network fault injected instead of the response.
+	Fault FaultType `json:"fault,omitempty" yaml:"fault,omitempty"`
+	// ResponseIndex is the index of the response picked in the responses list of the mock.
+	ResponseIndex *int `json:"response_index,omitempty" yaml:"response_index,omitempty"`
+	// Events are the server-sent events sent by the response.
+	Events []SentEvent `json:"events,omitempty" yaml:"events,omitempty"`
+	// Frames are the frames of the WebSocket conversation, in both directions.
+	Frames []WebSocketMessage `json:"frames,omitempty" yaml:"frames,omitempty"`
+	// Closest reports why the closest mocks didn't match a request no mock matched.
+	Closest []MatchReport `json:"closest,omitempty" yaml:"closest,omitempty"`
 }
 
 type Request struct {
-	Path        string      `json:"path"`
-	Method      string      `json:"method"`
-	Origin      string      `json:"origin"`
-	BodyString  string      `json:"body_string" yaml:"body_string"`
-	Body        interface{} `json:"body,omitempty" yaml:"body,omitempty"`
-	QueryParams url.Values  `json:"query_params,omitempty" yaml:"query_params,omitempty"`
-	Headers     http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
-	Date        time.Time   `json:"date" yaml:"date"`
+	Path            string            `json:"path"`
+	PathParams      map[string]string `json:"path_params,omitempty" yaml:"path_params,omitempty"`
+	Method          string            `json:"method"`
+	Origin          string            `json:"origin"`
+	Host            string            `json:"host,omitempty" yaml:"host,omitempty"`
+	Scheme          string            `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	TLS             *RequestTLS       `json:"tls,omitempty" yaml:"tls,omitempty"`
+	JWT             *RequestJWT       `json:"jwt,omitempty" yaml:"jwt,omitempty"`
+	GraphQL         *RequestGraphQL   `json:"graphql,omitempty" yaml:"graphql,omitempty"`
+	BodyString      string            `json:"body_string" yaml:"body_string"`
+	BodyBase64      bool              `json:"body_base64,omitempty" yaml:"body_base64,omitempty"`
+	ContentEncoding string            `json:"content_encoding,omitempty" yaml:"content_encoding,omitempty"`
+	Body            interface{}       `json:"body,omitempty" yaml:"body,omitempty"`
+	QueryParams     url.Values        `json:"query_params,omitempty" yaml:"query_params,omitempty"`
+	Headers         http.Header       `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Multipart       *MultipartForm    `json:"multipart,omitempty" yaml:"multipart,omitempty"`
+	Date            time.Time         `json:"date" yaml:"date"`
+}
+
+// RequestTLS describes the TLS connection of a request. ClientSubject is the distinguished name of
+// the client certificate (e.g. "CN=client,O=Acme"), empty when the client didn't send any, or when
+// the mock server doesn't request it (see the tls-client-cert flag).
+type RequestTLS struct {
+	ServerName    string `json:"server_name,omitempty" yaml:"server_name,omitempty"`
+	ClientSubject string `json:"client_subject,omitempty" yaml:"client_subject,omitempty"`
 }
 
 type Response struct {
-	Status  int         `json:"status"`
-	Body    interface{} `json:"body,omitempty" yaml:"body,omitempty"`
-	Headers http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
-	Date    time.Time   `json:"date" yaml:"date"`
+	Status     int         `json:"status"`
+	Body       interface{} `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyBase64 bool        `json:"body_base64,omitempty" yaml:"body_base64,omitempty"`
+	Headers    http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Date       time.Time   `json:"date" yaml:"date"`
 }
 
 func HTTPRequestToRequest(req *http.Request) Request {
@@ -60,10 +95,23 @@ func HTTPRequestToRequest(req *http.Request) Request {
 		}
 	}
 	req.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+	// Compressed bodies are matched and recorded decoded, the request body is left untouched.
+	var contentEncoding string
+	if encoding := req.Header.Get("Content-Encoding"); len(contentCodings(encoding)) > 0 && len(bodyBytes) > 0 {
+		if decoded, err := DecodeContentEncoding(bodyBytes, encoding); err != nil {
+			slog.Error("Failed to decode request body", "error", err)
+		} else {
+			bodyBytes, contentEncoding = decoded, encoding
+		}
+	}
+
+	// Binary bodies are kept base64 encoded, and matched as such by the matchers of the whole body.
+	bodyString, bodyBase64 := BinarySafeBody(bodyBytes)
 	var body interface{}
 	var tmp map[string]interface{}
 	if err := json.Unmarshal(bodyBytes, &tmp); err != nil {
-		body = string(bodyBytes)
+		body = bodyString
 	} else {
 		body = tmp
 	}
@@ -76,16 +124,85 @@ func HTTPRequestToRequest(req *http.Request) Request {
 		}
 	}
 	headers.Add("Host", req.Host)
+
+	multipartForm, err := ParseMultipartForm(req.Header, string(bodyBytes))
+	if err != nil {
+		slog.Error("Failed to read request body as multipart form", "error", err)
+	}
+
 	return Request{
-		Path:        req.URL.EscapedPath(),
-		Method:      req.Method,
-		Origin:      getOrigin(req),
-		Body:        body,
-		BodyString:  string(bodyBytes),
-		QueryParams: req.URL.Query(),
-		Headers:     headers,
-		Date:        time.Now(),
+		Path:            req.URL.EscapedPath(),
+		Method:          req.Method,
+		Origin:          getOrigin(req),
+		Host:            getHost(req),
+		Scheme:          getScheme(req),
+		TLS:             getTLS(req),
+		JWT:             decodeJWT(headers),
+		GraphQL:         getGraphQL(req, body, bodyString),
+		Body:            body,
+		BodyString:      bodyString,
+		BodyBase64:      bodyBase64,
+		ContentEncoding: contentEncoding,
+		QueryParams:     req.URL.Query(),
+		Headers:         headers,
+		Multipart:       multipartForm,
+		Date:            time.Now(),
+	}
+}
+
+// RawBody returns the body of the request as it was received, decoded when it was compressed.
+func (r Request) RawBody() []byte {
+	if r.BodyBase64 {
+		if body, err := base64.StdEncoding.DecodeString(r.BodyString); err == nil {
+			return body
+		}
+	}
+	return []byte(r.BodyString)
+}
+
+// getGraphQL returns the GraphQL operation of the requests which look like GraphQL requests.
+func getGraphQL(r *http.Request, body interface{}, bodyString string) *RequestGraphQL {
+	object, _ := body.(map[string]interface{})
+	_, hasQuery := object["query"].(string)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if !hasQuery && mediaType != "application/graphql" && !(r.Method == http.MethodGet && r.URL.Query().Has("query")) {
+		return nil
+	}
+	operation, err := ParseGraphQLRequest(r.Method, r.URL.Query(), r.Header, bodyString)
+	if err != nil {
+		return nil
+	}
+	return operation
+}
+
+// getHost returns the host the request was sent to, without port.
+func getHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		return r.Host
+	}
+	return host
+}
+
+func getScheme(r *http.Request) string {
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		return strings.ToLower(strings.TrimSpace(strings.Split(proto, ",")[0]))
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func getTLS(r *http.Request) *RequestTLS {
+	if r.TLS == nil {
+		return nil
+	}
+	tls := &RequestTLS{ServerName: r.TLS.ServerName}
+	if len(r.TLS.PeerCertificates) > 0 {
+		tls.ClientSubject = r.TLS.PeerCertificates[0].Subject.String()
 	}
+	return tls
 }
 
 func getOrigin(r *http.Request) string {
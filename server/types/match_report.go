@@ -0,0 +1,202 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"github.com/stretchr/objx"
+)
+
+// Mismatch describes a criterion of a mock that a request doesn't satisfy. Field locates the
+// criterion, e.g. "method", "headers.Accept" or "body.user.name".
+type Mismatch struct {
+	Field    string `json:"field" yaml:"field"`
+	Matcher  string `json:"matcher,omitempty" yaml:"matcher,omitempty"`
+	Expected string `json:"expected,omitempty" yaml:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty" yaml:"actual,omitempty"`
+	Message  string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// MatchReport tells how close a request is to a mock: how many of the mock criteria it satisfies,
+// and why it doesn't satisfy the others.
+type MatchReport struct {
+	MockID     string     `json:"mock_id,omitempty" yaml:"mock_id,omitempty"`
+	Matched    int        `json:"matched" yaml:"matched"`
+	Total      int        `json:"total" yaml:"total"`
+	Mismatches []Mismatch `json:"mismatches,omitempty" yaml:"mismatches,omitempty"`
+}
+
+// ClosestMock is a mock which doesn't match a request, with the report telling why.
+type ClosestMock struct {
+	Mock   *Mock       `json:"mock"`
+	Report MatchReport `json:"report"`
+}
+
+// ClosestMocks returns at most limit mocks, ranked by the number of criteria req satisfies. Mocks
+// satisfying as many criteria keep their order in mocks, where the last declared comes first.
+func ClosestMocks(mocks Mocks, req Request, limit int) []ClosestMock {
+	closest := make([]ClosestMock, 0, len(mocks))
+	for _, mock := range mocks {
+		report := mock.Request.Check(req)
+		if mock.State != nil {
+			report.MockID = mock.State.ID
+		}
+		closest = append(closest, ClosestMock{Mock: mock, Report: report})
+	}
+	sort.SliceStable(closest, func(i, j int) bool {
+		return closest[i].Report.Matched > closest[j].Report.Matched
+	})
+	if len(closest) > limit {
+		closest = closest[:limit]
+	}
+	return closest
+}
+
+// matchReporter records the outcome of the criteria of a mock. Matching a request only needs to
+// know whether every criterion is satisfied, so in fast mode it stops at the first mismatch and the
+// report is incomplete.
+type matchReporter struct {
+	report MatchReport
+	fast   bool
+}
+
+func (r *matchReporter) failed() bool {
+	return len(r.report.Mismatches) > 0
+}
+
+func (r *matchReporter) done() bool {
+	return r.fast && r.failed()
+}
+
+func (r *matchReporter) add(mismatch *Mismatch) {
+	r.report.Total++
+	if mismatch == nil {
+		r.report.Matched++
+		return
+	}
+	slog.Debug("Request did not match", "field", mismatch.Field)
+	r.report.Mismatches = append(r.report.Mismatches, *mismatch)
+}
+
+// keys returns the keys of a multimap matcher, sorted in report mode so that reports are stable.
+func (r *matchReporter) keys(mmm MultiMapMatcher) []string {
+	keys := make([]string, 0, len(mmm))
+	for key := range mmm {
+		keys = append(keys, key)
+	}
+	if !r.fast {
+		sort.Strings(keys)
+	}
+	return keys
+}
+
+func (r *matchReporter) string(field string, sm StringMatcher, actual string) {
+	if r.done() {
+		return
+	}
+	if message := sm.Explain(actual); message != "" {
+		r.add(newMismatch(field, sm, actual, message))
+		return
+	}
+	r.add(nil)
+}
+
+func (r *matchReporter) multimap(field string, mmm MultiMapMatcher, lookup func(key string) []string, strictness Strictness) {
+	for _, key := range r.keys(mmm) {
+		if r.done() {
+			return
+		}
+		values := lookup(key)
+		var message string
+		if len(values) == 0 {
+			message = fmt.Sprintf("Expected %q to be present (but it wasn't)!", key)
+		} else if strictness == NotStrict {
+			message = mmm[key].Explain(values)
+		} else {
+			message = mmm[key].ExplainStrict(values, strictness == StrictOrdered)
+		}
+		if message == "" {
+			r.add(nil)
+			continue
+		}
+		mismatch := &Mismatch{Field: field + "." + key, Message: message}
+		mismatch.Matcher, mismatch.Expected = mmm[key].describe()
+		if len(values) == 1 {
+			mismatch.Actual = values[0]
+		} else if len(values) > 1 {
+			b, _ := json.Marshal(values)
+			mismatch.Actual = string(b)
+		}
+		r.add(mismatch)
+	}
+}
+
+func (r *matchReporter) tls(tm TLSMatcher, tls *RequestTLS) {
+	if r.done() {
+		return
+	}
+	if tls == nil {
+		r.add(&Mismatch{Field: "tls", Message: "Expected a TLS connection (but the request was received without TLS)!"})
+		return
+	}
+	if tm.ServerName != nil {
+		r.string("tls.server_name", *tm.ServerName, tls.ServerName)
+	}
+	if tm.ClientSubject != nil {
+		r.string("tls.client_subject", *tm.ClientSubject, tls.ClientSubject)
+	}
+}
+
+// body checks the body matcher, form being the multipart form already parsed from the body, if
+// any, so that it isn't parsed again for each mock.
+func (r *matchReporter) body(bm BodyMatcher, headers http.Header, value string, form *MultipartForm) {
+	if r.done() {
+		return
+	}
+	if bm.bodyString != nil {
+		r.string("body", *bm.bodyString, value)
+		return
+	}
+	if bm.bodyJson == nil {
+		if message := bm.explainStructured(headers, value, form); message != "" {
+			b, _ := json.Marshal(bm)
+			r.add(&Mismatch{Field: "body", Matcher: bm.mode(), Expected: string(b), Message: message})
+			return
+		}
+		r.add(nil)
+		return
+	}
+	bm.reportJSON(r, headers, value, form)
+}
+
+// paths checks the values at the paths of the matchers, e.g. the fields of a JSON body.
+func (r *matchReporter) paths(field string, matchers map[string]StringMatcher, values objx.Map) {
+	paths := make([]string, 0, len(matchers))
+	for path := range matchers {
+		paths = append(paths, path)
+	}
+	if !r.fast {
+		sort.Strings(paths)
+	}
+	for _, path := range paths {
+		if r.done() {
+			return
+		}
+		matcher := matchers[path]
+		// A missing field is a null value for ShouldBeNull and ShouldNotBeNull.
+		data := values.Get(path).Data()
+		if message := matcher.Explain(data); message != "" {
+			r.add(newMismatch(field+"."+path, matcher, stringifyValue(data), message))
+			continue
+		}
+		r.add(nil)
+	}
+}
+
+func newMismatch(field string, sm StringMatcher, actual string, message string) *Mismatch {
+	matcher, expected := sm.describe()
+	return &Mismatch{Field: field, Matcher: matcher, Expected: expected, Actual: actual, Message: message}
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMatchReport(t *testing.T) {
+	var mocks Mocks
+	err := yaml.Unmarshal([]byte(`
+- request:
+    method: POST
+    path: /users
+    headers:
+      Content-Type: application/json
+      X-Tenant: {matcher: ShouldStartWith, value: acme}
+    body:
+      name: {matcher: ShouldNotBeEmpty}
+      age: {matcher: ShouldBeGreaterThan, value: 17}
+  response:
+    status: 201
+- request:
+    method: GET
+    path: /users
+  response:
+    status: 200
+- request:
+    method: DELETE
+    path: /users/{id}
+  response:
+    status: 204
+`), &mocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, mock := range mocks {
+		if err := mock.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		mock.Init()
+		mock.State.ID = []string{"create", "list", "delete"}[i]
+	}
+
+	req := Request{
+		Method:     http.MethodPost,
+		Path:       "/users",
+		Headers:    http.Header{"Content-Type": {"application/json"}, "X-Tenant": {"globex"}},
+		BodyString: `{"name": "alice", "age": 12}`,
+	}
+
+	report := mocks[0].Request.Check(req)
+	if report.Matched != 4 || report.Total != 6 {
+		t.Errorf("matched %d of %d criteria, want 4 of 6", report.Matched, report.Total)
+	}
+	if len(report.Mismatches) != 2 {
+		t.Fatalf("mismatches = %+v", report.Mismatches)
+	}
+	if got, want := report.Mismatches[0], (Mismatch{
+		Field:    "headers.X-Tenant",
+		Matcher:  "ShouldStartWith",
+		Expected: "acme",
+		Actual:   "globex",
+		Message:  report.Mismatches[0].Message,
+	}); !reflect.DeepEqual(got, want) || got.Message == "" {
+		t.Errorf("mismatch = %+v, want %+v", got, want)
+	}
+	if got := report.Mismatches[1]; got.Field != "body.age" || got.Actual != "12" || got.Expected != "17" {
+		t.Errorf("mismatch = %+v", got)
+	}
+
+	// A missing header is reported too, and Match agrees with Check.
+	delete(req.Headers, "Content-Type")
+	report = mocks[0].Request.Check(req)
+	if report.Matched != 3 || report.Mismatches[0].Field != "headers.Content-Type" || report.Mismatches[0].Actual != "" {
+		t.Errorf("report = %+v", report)
+	}
+	if mocks[0].Request.Match(req) {
+		t.Error("Match should agree with Check")
+	}
+
+	closest := ClosestMocks(mocks, req, 2)
+	if len(closest) != 2 {
+		t.Fatalf("closest = %+v", closest)
+	}
+	if closest[0].Report.MockID != "create" || closest[1].Report.MockID != "list" {
+		t.Errorf("closest mocks = %s, %s, want create, list", closest[0].Report.MockID, closest[1].Report.MockID)
+	}
+	if closest[1].Report.Matched != 1 || closest[1].Report.Mismatches[0].Field != "method" {
+		t.Errorf("report = %+v", closest[1].Report)
+	}
+}
+
+func TestMatchReportComposite(t *testing.T) {
+	matcher := StringMatcher{AnyOf: []StringMatcher{
+		{Matcher: "ShouldEqual", Value: "GET"},
+		{Matcher: "ShouldEqual", Value: "HEAD"},
+	}}
+	report := MockRequest{Method: matcher, Path: StringMatcher{Matcher: "ShouldMatch", Value: ".*"}}.Check(Request{Method: "POST", Path: "/"})
+	if len(report.Mismatches) != 1 {
+		t.Fatalf("mismatches = %+v", report.Mismatches)
+	}
+	mismatch := report.Mismatches[0]
+	if mismatch.Matcher != "any_of" || mismatch.Actual != "POST" || mismatch.Message == "" {
+		t.Errorf("mismatch = %+v", mismatch)
+	}
+	if want := `{"any_of":[{"matcher":"ShouldEqual","value":"GET"},{"matcher":"ShouldEqual","value":"HEAD"}]}`; mismatch.Expected != want {
+		t.Errorf("expected = %s, want %s", mismatch.Expected, want)
+	}
+}
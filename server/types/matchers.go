@@ -2,14 +2,17 @@ package types
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strings"
 
 	assertions "github.com/smarty/assertions"
 	"github.com/stretchr/objx"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -20,23 +23,43 @@ type Assertion func(actual interface{}, expected ...interface{}) string
 
 var asserts = map[string]Assertion{
 	"ShouldResemble":         assertions.ShouldResemble,
-	"ShouldAlmostEqual":      assertions.ShouldAlmostEqual,
+	"ShouldAlmostEqual":      ShouldAlmostEqual,
 	"ShouldContainSubstring": assertions.ShouldContainSubstring,
 	"ShouldEndWith":          assertions.ShouldEndWith,
 	"ShouldEqual":            assertions.ShouldEqual,
 	"ShouldEqualJSON":        assertions.ShouldEqualJSON,
+	ContainJSONMatcherName:   ShouldContainJSON,
 	"ShouldStartWith":        assertions.ShouldStartWith,
 	"ShouldBeEmpty":          ShouldBeEmpty,
 	"ShouldMatch":            ShouldMatch,
+	PathTemplateMatcherName:  ShouldMatchPathTemplate,
+	CIDRMatcherName:          ShouldBeInCIDR,
 
 	"ShouldNotResemble":         assertions.ShouldNotResemble,
-	"ShouldNotAlmostEqual":      assertions.ShouldNotAlmostEqual,
+	"ShouldNotAlmostEqual":      ShouldNotAlmostEqual,
 	"ShouldNotContainSubstring": assertions.ShouldNotContainSubstring,
 	"ShouldNotEndWith":          assertions.ShouldNotEndWith,
 	"ShouldNotEqual":            assertions.ShouldNotEqual,
 	"ShouldNotStartWith":        assertions.ShouldNotStartWith,
 	"ShouldNotBeEmpty":          ShouldNotBeEmpty,
 	"ShouldNotMatch":            ShouldNotMatch,
+
+	"ShouldBeGreaterThan":          ShouldBeGreaterThan,
+	"ShouldBeGreaterThanOrEqualTo": ShouldBeGreaterThanOrEqualTo,
+	"ShouldBeLessThan":             ShouldBeLessThan,
+	"ShouldBeLessThanOrEqualTo":    ShouldBeLessThanOrEqualTo,
+	"ShouldBeBetween":              ShouldBeBetween,
+	"ShouldNotBeBetween":           ShouldNotBeBetween,
+	"ShouldHaveLength":             ShouldHaveLength,
+	"ShouldBeTrue":                 ShouldBeTrue,
+	"ShouldBeFalse":                ShouldBeFalse,
+	"ShouldBeNull":                 ShouldBeNull,
+	"ShouldNotBeNull":              ShouldNotBeNull,
+	"ShouldBeString":               ShouldBeString,
+	"ShouldBeNumber":               ShouldBeNumber,
+	"ShouldBeBoolean":              ShouldBeBoolean,
+	"ShouldBeArray":                ShouldBeArray,
+	"ShouldBeObject":               ShouldBeObject,
 }
 
 func ShouldMatch(value interface{}, patterns ...interface{}) string {
@@ -51,9 +74,13 @@ func ShouldMatch(value interface{}, patterns ...interface{}) string {
 			return "ShouldMatch works only with strings"
 		}
 
-		if match, err := regexp.MatchString(patternString, valueString); !match || err != nil {
+		re, err := regexp.Compile(patternString)
+		if err != nil {
 			return fmt.Sprintf("Expected %q to match %q (but it didn't)!", valueString, patternString)
 		}
+		if message := explainRegexp("ShouldMatch", re, patternString, valueString); message != "" {
+			return message
+		}
 	}
 
 	return ""
@@ -79,46 +106,218 @@ func ShouldNotMatch(value interface{}, patterns ...interface{}) string {
 			return "ShouldNotMatch works only with strings"
 		}
 
-		if match, err := regexp.MatchString(patternString, valueString); match && err == nil {
-			return fmt.Sprintf("Expected %q to not match %q (but it did)!", valueString, patternString)
+		if re, err := regexp.Compile(patternString); err == nil {
+			if message := explainRegexp("ShouldNotMatch", re, patternString, valueString); message != "" {
+				return message
+			}
 		}
 	}
 
 	return ""
 }
 
+// StringMatcher is either a leaf matcher, applying the Matcher assertion with Value, or a
+// composite node combining other matchers: AnyOf matches when one of its matchers does, AllOf when
+// all of them do, and Not when its matcher doesn't. Leaves and composite nodes can be nested
+// freely, e.g. {not: {any_of: [A, B]}}.
 type StringMatcher struct {
 	Matcher string `json:"matcher" yaml:"matcher,flow"`
 	Value   string `json:"value" yaml:"value,flow"`
+	// Arrays sets how ShouldContainJSON compares arrays.
+	Arrays string `json:"arrays,omitempty" yaml:"arrays,omitempty"`
+
+	AnyOf []StringMatcher `json:"any_of,omitempty" yaml:"any_of,omitempty"`
+	AllOf []StringMatcher `json:"all_of,omitempty" yaml:"all_of,omitempty"`
+	Not   *StringMatcher  `json:"not,omitempty" yaml:"not,omitempty"`
+
+	// re is the regular expression of ShouldMatch, ShouldNotMatch and ShouldMatchPathTemplate
+	// matchers, compiled by Validate.
+	re *regexp.Regexp
+}
+
+func (sm StringMatcher) IsComposite() bool {
+	return sm.AnyOf != nil || sm.AllOf != nil || sm.Not != nil
 }
 
-func (sm StringMatcher) Validate() error {
+// Validate checks the matcher, and compiles its regular expression, if any, so that matching a
+// request doesn't compile it again.
+func (sm *StringMatcher) Validate() error {
+	if sm.IsComposite() {
+		return sm.validateComposite()
+	}
+
 	if _, ok := asserts[sm.Matcher]; !ok {
 		return fmt.Errorf("invalid matcher %q", sm.Matcher)
 	}
 
-	// Try to compile ShouldMatch regular expressions
-	if sm.Matcher == "ShouldMatch" || sm.Matcher == "ShouldNotMatch" {
-		if _, err := regexp.Compile(sm.Value); err != nil {
-			return fmt.Errorf("invalid regular expression provided to %q operator: %v", sm.Matcher, sm.Value)
+	// Compile regular expressions, once for all the requests to match
+	re, err := sm.compileRegexp()
+	if err != nil {
+		if sm.Matcher == PathTemplateMatcherName {
+			return fmt.Errorf("invalid path template provided to %q operator: %v", sm.Matcher, err)
+		}
+		return fmt.Errorf("invalid regular expression provided to %q operator: %v", sm.Matcher, sm.Value)
+	}
+	sm.re = re
+	if sm.Arrays != "" && sm.Matcher != ContainJSONMatcherName {
+		return fmt.Errorf("arrays can only be provided to %q operator", ContainJSONMatcherName)
+	}
+	if sm.Matcher == ContainJSONMatcherName {
+		if !jsonArrayModes[sm.Arrays] {
+			return fmt.Errorf("invalid arrays %q provided to %q operator, expected %q, %q or %q", sm.Arrays, sm.Matcher, JSONArraysOrdered, JSONArraysUnordered, JSONArraysContains)
+		}
+		if !json.Valid([]byte(sm.Value)) {
+			return fmt.Errorf("invalid JSON document provided to %q operator: %v", sm.Matcher, sm.Value)
+		}
+	}
+	if sm.Matcher == CIDRMatcherName {
+		if _, err := parsePrefixes(sm.Value); err != nil {
+			return fmt.Errorf("invalid value provided to %q operator: %v", sm.Matcher, err)
+		}
+	}
+	return validateTypedMatcher(*sm)
+}
+
+func (sm *StringMatcher) validateComposite() error {
+	nodes := 0
+	for _, set := range []bool{sm.AnyOf != nil, sm.AllOf != nil, sm.Not != nil, sm.Matcher != ""} {
+		if set {
+			nodes++
+		}
+	}
+	if nodes > 1 {
+		return errors.New("a matcher must define only one of matcher, any_of, all_of or not")
+	}
+	if sm.AnyOf != nil && len(sm.AnyOf) == 0 {
+		return errors.New("any_of must contain at least one matcher")
+	}
+	if sm.AllOf != nil && len(sm.AllOf) == 0 {
+		return errors.New("all_of must contain at least one matcher")
+	}
+	for _, children := range [][]StringMatcher{sm.AnyOf, sm.AllOf} {
+		for i := range children {
+			if err := children[i].Validate(); err != nil {
+				return err
+			}
 		}
 	}
+	if sm.Not != nil {
+		return sm.Not.Validate()
+	}
 	return nil
 }
 
 func (sm StringMatcher) Match(value string) bool {
+	return sm.MatchValue(value)
+}
+
+// MatchValue is like Match but accepts a decoded JSON value, which typed matchers (see
+// typedMatchers) check as is. Other matchers are given its string form.
+func (sm StringMatcher) MatchValue(value interface{}) bool {
+	return sm.Explain(value) == ""
+}
+
+// Explain returns why value doesn't satisfy the matcher, or an empty string when it does.
+func (sm StringMatcher) Explain(value interface{}) string {
+	switch {
+	case sm.AnyOf != nil:
+		messages := make([]string, 0, len(sm.AnyOf))
+		for _, child := range sm.AnyOf {
+			message := child.Explain(value)
+			if message == "" {
+				return ""
+			}
+			messages = append(messages, message)
+		}
+		return fmt.Sprintf("Expected any of the matchers to succeed (but none did):\n%s", strings.Join(messages, "\n"))
+	case sm.AllOf != nil:
+		for _, child := range sm.AllOf {
+			if message := child.Explain(value); message != "" {
+				return message
+			}
+		}
+		return ""
+	case sm.Not != nil:
+		if sm.Not.Explain(value) == "" {
+			return fmt.Sprintf("Expected %v not to satisfy %s (but it did)!", value, sm.Not)
+		}
+		return ""
+	}
+
+	if _, isString := value.(string); !isString && !typedMatchers[sm.Matcher] {
+		value = stringifyValue(value)
+	}
+
+	if valueString, isString := value.(string); isString && sm.re != nil {
+		return explainRegexp(sm.Matcher, sm.re, sm.Value, valueString)
+	}
+
 	matcher := asserts[sm.Matcher]
 	if matcher == nil {
 		slog.Error("Invalid matcher", "matcher", sm.Matcher)
-		return false
+		return fmt.Sprintf("invalid matcher %q", sm.Matcher)
 	}
 
-	if res := matcher(value, sm.Value); res != "" {
+	expected := []interface{}{sm.Value}
+	if sm.Arrays != "" {
+		expected = append(expected, sm.Arrays)
+	}
+	res := matcher(value, expected...)
+	if res != "" {
 		slog.Debug(fmt.Sprintf("Value doesn't match:\n%s", res))
-		return false
 	}
+	return res
+}
+
+// describe returns the name of the matcher and its expected value, as shown in match reports.
+// Composite matchers are named after their kind, their expected value being their JSON form.
+func (sm StringMatcher) describe() (string, string) {
+	if !sm.IsComposite() {
+		return sm.Matcher, sm.Value
+	}
+	kind := "not"
+	if sm.AnyOf != nil {
+		kind = "any_of"
+	} else if sm.AllOf != nil {
+		kind = "all_of"
+	}
+	b, _ := json.Marshal(sm)
+	return kind, string(b)
+}
+
+func (sm StringMatcher) String() string {
+	if sm.IsComposite() {
+		_, expected := sm.describe()
+		return expected
+	}
+	return fmt.Sprintf("%s %q", sm.Matcher, sm.Value)
+}
+
+type stringMatcherSerialization struct {
+	Matcher string `json:"matcher" yaml:"matcher,flow"`
+	Value   string `json:"value" yaml:"value,flow"`
+	Arrays  string `json:"arrays,omitempty" yaml:"arrays,omitempty"`
+}
 
-	return true
+type compositeMatcherSerialization struct {
+	AnyOf []StringMatcher `json:"any_of,omitempty" yaml:"any_of,omitempty"`
+	AllOf []StringMatcher `json:"all_of,omitempty" yaml:"all_of,omitempty"`
+	Not   *StringMatcher  `json:"not,omitempty" yaml:"not,omitempty"`
+}
+
+func (sm StringMatcher) serialization() interface{} {
+	if sm.IsComposite() {
+		return compositeMatcherSerialization{AnyOf: sm.AnyOf, AllOf: sm.AllOf, Not: sm.Not}
+	}
+	return stringMatcherSerialization{Matcher: sm.Matcher, Value: sm.Value, Arrays: sm.Arrays}
+}
+
+func (sm StringMatcher) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sm.serialization())
+}
+
+func (sm StringMatcher) MarshalYAML() (interface{}, error) {
+	return sm.serialization(), nil
 }
 
 func (sm *StringMatcher) UnmarshalJSON(data []byte) error {
@@ -130,16 +329,28 @@ func (sm *StringMatcher) UnmarshalJSON(data []byte) error {
 	}
 
 	var res struct {
-		Matcher string `json:"matcher"`
-		Value   string `json:"value"`
+		Matcher string          `json:"matcher"`
+		Value   json.RawMessage `json:"value"`
+		Arrays  string          `json:"arrays"`
+		AnyOf   []StringMatcher `json:"any_of"`
+		AllOf   []StringMatcher `json:"all_of"`
+		Not     *StringMatcher  `json:"not"`
 	}
 
 	if err := json.Unmarshal(data, &res); err != nil {
 		return err
 	}
 
+	value, err := jsonScalarString(res.Value)
+	if err != nil {
+		return err
+	}
 	sm.Matcher = res.Matcher
-	sm.Value = res.Value
+	sm.Value = value
+	sm.Arrays = res.Arrays
+	sm.AnyOf = res.AnyOf
+	sm.AllOf = res.AllOf
+	sm.Not = res.Not
 	return sm.Validate()
 }
 
@@ -152,38 +363,96 @@ func (sm *StringMatcher) UnmarshalYAML(unmarshal func(interface{}) error) error
 	}
 
 	var res struct {
-		Matcher string `yaml:"matcher,flow"`
-		Value   string `yaml:"value,flow"`
+		Matcher string          `yaml:"matcher,flow"`
+		Value   yaml.Node       `yaml:"value,flow"`
+		Arrays  string          `yaml:"arrays"`
+		AnyOf   []StringMatcher `yaml:"any_of"`
+		AllOf   []StringMatcher `yaml:"all_of"`
+		Not     *StringMatcher  `yaml:"not"`
 	}
 
 	if err := unmarshal(&res); err != nil {
 		return err
 	}
 
+	value, err := yamlValueString(&res.Value)
+	if err != nil {
+		return err
+	}
 	sm.Matcher = res.Matcher
-	sm.Value = res.Value
+	sm.Value = value
+	sm.Arrays = res.Arrays
+	sm.AnyOf = res.AnyOf
+	sm.AllOf = res.AllOf
+	sm.Not = res.Not
 	return sm.Validate()
 }
 
+// jsonScalarString reads the value of a matcher, which may be written as a JSON number or boolean
+// (e.g. {"matcher": "ShouldBeGreaterThan", "value": 10}) as well as a string. Objects and arrays,
+// e.g. the documents of ShouldEqualJSON or ShouldContainJSON, are read as their JSON form.
+func jsonScalarString(data json.RawMessage) (string, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return "", nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", err
+	}
+	return stringifyValue(value), nil
+}
+
+// yamlValueString is the YAML counterpart of jsonScalarString. Scalars are read verbatim.
+func yamlValueString(node *yaml.Node) (string, error) {
+	switch {
+	case node.Kind == 0 || node.Tag == "!!null":
+		return "", nil
+	case node.Kind == yaml.ScalarNode:
+		return node.Value, nil
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid matcher value: %v", err)
+	}
+	return string(b), nil
+}
+
 type StringMatcherSlice []StringMatcher
 
 func (sms StringMatcherSlice) Match(values []string) bool {
+	return sms.Explain(values) == ""
+}
+
+// Explain returns why values don't satisfy the matchers, or an empty string when they do: each
+// matcher must be satisfied by one of the values.
+func (sms StringMatcherSlice) Explain(values []string) string {
 	if len(sms) > len(values) {
-		return false
+		return fmt.Sprintf("Expected at least %d values (but got %d)!", len(sms), len(values))
 	}
 	for _, matcher := range sms {
-		matched := false
+		var message string
 		for _, v := range values {
-			if matcher.Match(v) {
-				matched = true
+			if message = matcher.Explain(v); message == "" {
 				break
 			}
 		}
-		if !matched {
-			return false
+		if message != "" {
+			return message
 		}
 	}
-	return true
+	return ""
+}
+
+func (sms StringMatcherSlice) describe() (string, string) {
+	if len(sms) == 1 {
+		return sms[0].describe()
+	}
+	b, _ := json.Marshal(sms)
+	return "all_of", string(b)
 }
 
 func (sms *StringMatcherSlice) UnmarshalJSON(data []byte) error {
@@ -237,16 +506,9 @@ func (sms *StringMatcherSlice) UnmarshalYAML(unmarshal func(interface{}) error)
 type MultiMapMatcher map[string]StringMatcherSlice
 
 func (mmm MultiMapMatcher) Match(values map[string][]string) bool {
-	if len(mmm) > len(values) {
-		return false
-	}
-	for key, matcherValue := range mmm {
-		value, ok := values[key]
-		if !ok || !matcherValue.Match(value) {
-			return false
-		}
-	}
-	return true
+	r := matchReporter{fast: true}
+	r.multimap("", mmm, func(key string) []string { return values[key] }, NotStrict)
+	return !r.failed()
 }
 
 // MatchHeaders is like Match but resolves header names case-insensitively (RFC 7230 §3.2: header
@@ -254,68 +516,173 @@ func (mmm MultiMapMatcher) Match(values map[string][]string) bool {
 // "Content-Type". Header *values* are still matched exactly. The declared casing is never altered —
 // http.Header.Values only canonicalizes the key it looks up with, not the stored keys.
 func (mmm MultiMapMatcher) MatchHeaders(headers http.Header) bool {
-	if len(mmm) > len(headers) {
-		return false
-	}
-	for key, matcherValue := range mmm {
-		if value := headers.Values(key); len(value) == 0 || !matcherValue.Match(value) {
-			return false
-		}
-	}
-	return true
+	r := matchReporter{fast: true}
+	r.multimap("", mmm, headers.Values, NotStrict)
+	return !r.failed()
 }
 
 type BodyMatcher struct {
 	bodyString *StringMatcher
 	bodyJson   map[string]StringMatcher
+	bodyXPath  *XPathMatcher
+	bodySchema *JSONSchemaMatcher
+	bodyPath   *JSONPathMatcher
+}
+
+// Validate prepares the matchers that are costly to build, such as JSON schemas, so that they are
+// compiled once when the mock is registered instead of on every request.
+func (bm *BodyMatcher) Validate() error {
+	if bm.bodySchema != nil {
+		return bm.bodySchema.Validate()
+	}
+	return nil
 }
 
 func (bm BodyMatcher) Match(headers http.Header, value string) bool {
-	if bm.bodyString != nil {
-		return bm.bodyString.Match(value)
+	r := matchReporter{fast: true}
+	r.body(bm, headers, value, nil)
+	return !r.failed()
+}
+
+// mode names the kind of a structured body matcher in match reports.
+func (bm BodyMatcher) mode() string {
+	switch {
+	case bm.bodyXPath != nil:
+		return "xpath"
+	case bm.bodySchema != nil:
+		return JSONSchemaMatcherName
+	case bm.bodyPath != nil:
+		return "json_path"
+	default:
+		return ""
 	}
+}
 
-	if headers.Get("Content-Type") == "application/x-www-form-urlencoded" {
-		m, err := url.ParseQuery(value)
-		if err != nil {
-			slog.Error("Failed to read request body as encoded form", "error", err)
-		} else if b, err := json.Marshal(m); err != nil {
-			slog.Error("Failed to serialize form body as JSON", "error", err)
-		} else {
-			value = string(b)
+// explainStructured returns why the body doesn't satisfy an XPath, JSON Schema or JSONPath
+// matcher, or an empty string when it does.
+func (bm BodyMatcher) explainStructured(headers http.Header, value string, form *MultipartForm) string {
+	switch {
+	case bm.bodyXPath != nil:
+		if !bm.bodyXPath.Match(value) {
+			return "Expected body to satisfy the XPath expressions (but it didn't)!"
+		}
+	case bm.bodySchema != nil:
+		return bm.bodySchema.Explain(value)
+	case bm.bodyPath != nil:
+		if !bm.bodyPath.Match(formBodyAsJSON(headers, value, form)) {
+			return "Expected body to satisfy the JSONPath expressions (but it didn't)!"
 		}
 	}
+	return ""
+}
 
-	j, err := objx.FromJSON(value)
+// reportJSON checks each path of a JSON body matcher. URL-encoded and multipart form bodies are
+// matched like JSON bodies (see formBodyAsJSON).
+func (bm BodyMatcher) reportJSON(r *matchReporter, headers http.Header, value string, form *MultipartForm) {
+	j, err := objx.FromJSON(formBodyAsJSON(headers, value, form))
 	if err != nil {
-		return false
+		r.add(&Mismatch{Field: "body", Message: fmt.Sprintf("Expected body to be a JSON object (but it wasn't): %v", err)})
+		return
 	}
-	for path, matcher := range bm.bodyJson {
-		value := j.Get(path)
-		if value == nil {
-			return false
+
+	r.paths("body", bm.bodyJson, j)
+}
+
+// formBodyAsJSON converts an URL-encoded or multipart form body to JSON, so that its fields can be
+// matched like the fields of a JSON body. A multipart body is converted to a MultipartForm, with
+// its fields under "fields" and its files under "files", form being the already parsed one if any.
+// Other bodies are returned unchanged.
+func formBodyAsJSON(headers http.Header, value string, form *MultipartForm) string {
+	if form == nil {
+		var err error
+		if form, err = ParseMultipartForm(headers, value); err != nil {
+			slog.Error("Failed to read request body as multipart form", "error", err)
+			return value
 		}
-		if ok := matcher.Match(value.String()); !ok {
-			return false
+	}
+	if form != nil {
+		b, err := json.Marshal(form)
+		if err != nil {
+			slog.Error("Failed to serialize multipart body as JSON", "error", err)
+			return value
 		}
+		return string(b)
+	}
+
+	if headers.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		return value
+	}
+	m, err := url.ParseQuery(value)
+	if err != nil {
+		slog.Error("Failed to read request body as encoded form", "error", err)
+		return value
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		slog.Error("Failed to serialize form body as JSON", "error", err)
+		return value
 	}
-	return true
+	return string(b)
+}
+
+// isStringBody tells a matcher of the whole body apart from a map of JSON paths. A composite
+// matcher of the whole body is written with any_of or all_of, as a map holding a single "not" key
+// is a matcher for the "not" JSON field.
+func isStringBody(s StringMatcher) bool {
+	if s.AnyOf != nil || s.AllOf != nil {
+		return true
+	}
+	_, ok := asserts[s.Matcher]
+	return ok
 }
 
 func (bm BodyMatcher) MarshalJSON() ([]byte, error) {
 	if bm.bodyString != nil {
 		return json.Marshal(bm.bodyString)
 	}
+	if bm.bodyXPath != nil {
+		return json.Marshal(bm.bodyXPath)
+	}
+	if bm.bodySchema != nil {
+		return json.Marshal(bm.bodySchema)
+	}
+	if bm.bodyPath != nil {
+		return json.Marshal(bm.bodyPath)
+	}
 	return json.Marshal(bm.bodyJson)
 }
 
 func (bm *BodyMatcher) UnmarshalJSON(data []byte) error {
 	var s StringMatcher
-	if err := json.Unmarshal(data, &s); err == nil {
-		if _, ok := asserts[s.Matcher]; ok {
-			bm.bodyString = &s
-			return nil
+	if err := json.Unmarshal(data, &s); err == nil && isStringBody(s) {
+		bm.bodyString = &s
+		return nil
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err == nil && isSchemaBody(raw) {
+		sm, err := newJSONSchemaMatcher(raw)
+		if err != nil {
+			return err
+		}
+		bm.bodySchema = sm
+		return nil
+	}
+	if isJSONPathBody(raw) {
+		var jm JSONPathMatcher
+		if err := json.Unmarshal(data, &jm); err != nil {
+			return err
+		}
+		bm.bodyPath = &jm
+		return jm.Validate()
+	}
+	if isXPathBody(raw) {
+		var xm XPathMatcher
+		if err := json.Unmarshal(data, &xm); err != nil {
+			return err
 		}
+		bm.bodyXPath = &xm
+		return xm.Validate()
 	}
 
 	var res map[string]StringMatcher
@@ -330,16 +697,49 @@ func (bm BodyMatcher) MarshalYAML() (interface{}, error) {
 	if bm.bodyString != nil {
 		return bm.bodyString, nil
 	}
+	if bm.bodyXPath != nil {
+		return bm.bodyXPath, nil
+	}
+	if bm.bodySchema != nil {
+		return bm.bodySchema, nil
+	}
+	if bm.bodyPath != nil {
+		return bm.bodyPath, nil
+	}
 	return bm.bodyJson, nil
 }
 
 func (bm *BodyMatcher) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var s StringMatcher
-	if err := unmarshal(&s); err == nil {
-		if _, ok := asserts[s.Matcher]; ok {
-			bm.bodyString = &s
-			return nil
+	if err := unmarshal(&s); err == nil && isStringBody(s) {
+		bm.bodyString = &s
+		return nil
+	}
+
+	var raw map[string]interface{}
+	if err := unmarshal(&raw); err == nil && isSchemaBody(raw) {
+		sm, err := newJSONSchemaMatcher(raw)
+		if err != nil {
+			return err
 		}
+		bm.bodySchema = sm
+		return nil
+	}
+	if isJSONPathBody(raw) {
+		var jm JSONPathMatcher
+		if err := unmarshal(&jm); err != nil {
+			return err
+		}
+		bm.bodyPath = &jm
+		return jm.Validate()
+	}
+	if isXPathBody(raw) {
+		var xm XPathMatcher
+		if err := unmarshal(&xm); err != nil {
+			return err
+		}
+		bm.bodyXPath = &xm
+		return xm.Validate()
 	}
 
 	var res map[string]StringMatcher
@@ -358,6 +758,30 @@ func NewJSONBodyMatcher(
 	}
 }
 
+func NewXPathBodyMatcher(
+	bodyXPath XPathMatcher,
+) *BodyMatcher {
+	return &BodyMatcher{
+		bodyXPath: &bodyXPath,
+	}
+}
+
+func NewJSONSchemaBodyMatcher(
+	schema interface{},
+) *BodyMatcher {
+	return &BodyMatcher{
+		bodySchema: &JSONSchemaMatcher{Schema: schema},
+	}
+}
+
+func NewJSONPathBodyMatcher(
+	bodyPath JSONPathMatcher,
+) *BodyMatcher {
+	return &BodyMatcher{
+		bodyPath: &bodyPath,
+	}
+}
+
 func NewStringBodyMatcher(
 	bodyString StringMatcher,
 ) *BodyMatcher {
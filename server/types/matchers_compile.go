@@ -0,0 +1,42 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// compileRegexp compiles the regular expression of a ShouldMatch, ShouldNotMatch or
+// ShouldMatchPathTemplate matcher. Other matchers have none.
+func (sm StringMatcher) compileRegexp() (*regexp.Regexp, error) {
+	switch sm.Matcher {
+	case "ShouldMatch", "ShouldNotMatch":
+		return regexp.Compile(sm.Value)
+	case PathTemplateMatcherName:
+		return compilePathTemplate(sm.Value)
+	}
+	return nil, nil
+}
+
+// compiledRegexp returns the regular expression of the matcher, compiled once by Validate when the
+// mock was registered or loaded, or compiled now for a matcher built in code.
+func (sm StringMatcher) compiledRegexp() (*regexp.Regexp, error) {
+	if sm.re != nil {
+		return sm.re, nil
+	}
+	return sm.compileRegexp()
+}
+
+// explainRegexp returns why value doesn't satisfy a ShouldMatch, ShouldNotMatch or
+// ShouldMatchPathTemplate matcher, whose pattern compiled to re, or an empty string when it does.
+func explainRegexp(matcher string, re *regexp.Regexp, pattern, value string) string {
+	matched := re.MatchString(value)
+	switch {
+	case matcher == "ShouldNotMatch" && matched:
+		return fmt.Sprintf("Expected %q to not match %q (but it did)!", value, pattern)
+	case matcher == PathTemplateMatcherName && !matched:
+		return fmt.Sprintf("Expected %q to match path template %q (but it didn't)!", value, pattern)
+	case matcher == "ShouldMatch" && !matched:
+		return fmt.Sprintf("Expected %q to match %q (but it didn't)!", value, pattern)
+	}
+	return ""
+}
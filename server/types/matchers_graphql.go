@@ -0,0 +1,147 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"github.com/stretchr/objx"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// GraphQLMatcher matches the operation of a GraphQL request: its name, its type (query, mutation
+// or subscription), the fields it selects at the root, and its variables, matched by path like the
+// fields of a JSON body. Each of the Fields matchers must be satisfied by one of the root fields.
+type GraphQLMatcher struct {
+	OperationName *StringMatcher           `json:"operation_name,omitempty" yaml:"operation_name,omitempty"`
+	OperationType *StringMatcher           `json:"operation_type,omitempty" yaml:"operation_type,omitempty"`
+	Fields        StringMatcherSlice       `json:"fields,omitempty" yaml:"fields,omitempty"`
+	Variables     map[string]StringMatcher `json:"variables,omitempty" yaml:"variables,omitempty"`
+}
+
+// RequestGraphQL is the operation of a GraphQL request.
+type RequestGraphQL struct {
+	Query         string                 `json:"query" yaml:"query"`
+	OperationName string                 `json:"operation_name,omitempty" yaml:"operation_name,omitempty"`
+	OperationType string                 `json:"operation_type" yaml:"operation_type"`
+	Fields        []string               `json:"fields" yaml:"fields"`
+	Variables     map[string]interface{} `json:"variables,omitempty" yaml:"variables,omitempty"`
+}
+
+func (gm GraphQLMatcher) Match(req Request) bool {
+	r := matchReporter{fast: true}
+	r.graphql(gm, req)
+	return !r.failed()
+}
+
+type graphQLParams struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// ParseGraphQLRequest reads the GraphQL operation of a request, sent in the query parameters of a
+// GET request, or in the body of a POST request, as JSON or as an application/graphql document.
+func ParseGraphQLRequest(method string, query url.Values, headers http.Header, body string) (*RequestGraphQL, error) {
+	var params graphQLParams
+	mediaType, _, _ := mime.ParseMediaType(headers.Get("Content-Type"))
+	switch {
+	case method == http.MethodGet:
+		params.Query = query.Get("query")
+		params.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &params.Variables); err != nil {
+				return nil, fmt.Errorf("invalid GraphQL variables: %v", err)
+			}
+		}
+	case mediaType == "application/graphql":
+		params.Query = body
+		params.OperationName = query.Get("operationName")
+	default:
+		if err := json.Unmarshal([]byte(body), &params); err != nil {
+			return nil, fmt.Errorf("invalid GraphQL request body: %v", err)
+		}
+	}
+	if params.Query == "" {
+		return nil, errors.New("missing GraphQL query")
+	}
+
+	document, err := parser.ParseQuery(&ast.Source{Input: params.Query})
+	if err != nil {
+		return nil, fmt.Errorf("invalid GraphQL query: %v", err)
+	}
+	operation := document.Operations.ForName(params.OperationName)
+	if operation == nil {
+		if params.OperationName == "" {
+			return nil, errors.New("the GraphQL operation to execute must be named when the document holds several")
+		}
+		return nil, fmt.Errorf("GraphQL operation %q not found", params.OperationName)
+	}
+
+	fields := []string{}
+	collectGraphQLFields(operation.SelectionSet, document.Fragments, map[string]bool{}, &fields)
+	return &RequestGraphQL{
+		Query:         params.Query,
+		OperationName: operation.Name,
+		OperationType: string(operation.Operation),
+		Fields:        fields,
+		Variables:     params.Variables,
+	}, nil
+}
+
+// collectGraphQLFields appends the names of the fields of a selection set, including the ones
+// selected through fragments, without duplicates.
+func collectGraphQLFields(selections ast.SelectionSet, fragments ast.FragmentDefinitionList, visited map[string]bool, fields *[]string) {
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if !visited["field:"+s.Name] {
+				visited["field:"+s.Name] = true
+				*fields = append(*fields, s.Name)
+			}
+		case *ast.InlineFragment:
+			collectGraphQLFields(s.SelectionSet, fragments, visited, fields)
+		case *ast.FragmentSpread:
+			if fragment := fragments.ForName(s.Name); fragment != nil && !visited["fragment:"+s.Name] {
+				visited["fragment:"+s.Name] = true
+				collectGraphQLFields(fragment.SelectionSet, fragments, visited, fields)
+			}
+		}
+	}
+}
+
+func (r *matchReporter) graphql(gm GraphQLMatcher, req Request) {
+	if r.done() {
+		return
+	}
+	operation := req.GraphQL
+	if operation == nil {
+		var err error
+		if operation, err = ParseGraphQLRequest(req.Method, req.QueryParams, req.Headers, req.BodyString); err != nil {
+			r.add(&Mismatch{Field: "graphql", Message: fmt.Sprintf("Expected a GraphQL request (but it wasn't): %v", err)})
+			return
+		}
+	}
+	r.add(nil)
+
+	if gm.OperationName != nil {
+		r.string("graphql.operation_name", *gm.OperationName, operation.OperationName)
+	}
+	if gm.OperationType != nil {
+		r.string("graphql.operation_type", *gm.OperationType, operation.OperationType)
+	}
+	if gm.Fields != nil && !r.done() {
+		if message := gm.Fields.Explain(operation.Fields); message != "" {
+			mismatch := &Mismatch{Field: "graphql.fields", Actual: stringifyValue(operation.Fields), Message: message}
+			mismatch.Matcher, mismatch.Expected = gm.Fields.describe()
+			r.add(mismatch)
+		} else {
+			r.add(nil)
+		}
+	}
+	r.paths("graphql.variables", gm.Variables, objx.Map(operation.Variables))
+}
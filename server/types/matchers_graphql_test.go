@@ -0,0 +1,103 @@
+package types
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestGraphQLMatcher(t *testing.T) {
+	var mock Mock
+	err := yaml.Unmarshal([]byte(`
+request:
+  method: POST
+  path: /graphql
+  graphql:
+    operation_name: GetUser
+    operation_type: query
+    fields: [user, {matcher: ShouldStartWith, value: perm}]
+    variables:
+      id: "42"
+      filter.active: {matcher: ShouldBeTrue}
+response:
+  status: 200
+`), &mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(body string) Request {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return HTTPRequestToRequest(req)
+	}
+	query := `query GetUser($id: ID!, $filter: Filter) {
+		user(id: $id) { name }
+		...Permissions
+	}
+	fragment Permissions on Query { permissions { name } }
+	mutation DeleteUser { deleteUser(id: 1) }`
+
+	req := post(`{"query": ` + jsonString(query) + `, "operationName": "GetUser", "variables": {"id": "42", "filter": {"active": true}}}`)
+	if !reflect.DeepEqual(req.GraphQL, &RequestGraphQL{
+		Query:         query,
+		OperationName: "GetUser",
+		OperationType: "query",
+		Fields:        []string{"user", "permissions"},
+		Variables:     map[string]interface{}{"id": "42", "filter": map[string]interface{}{"active": true}},
+	}) {
+		t.Errorf("graphql = %+v", req.GraphQL)
+	}
+	if !mock.Request.Match(req) {
+		t.Error("request with the matching operation should match")
+	}
+
+	for _, body := range []string{
+		`{"query": ` + jsonString(query) + `, "operationName": "DeleteUser"}`,
+		`{"query": ` + jsonString(query) + `, "operationName": "GetUser", "variables": {"id": "43", "filter": {"active": true}}}`,
+		`{"query": "query GetUser { user { name } }", "variables": {"id": "42", "filter": {"active": true}}}`,
+		`{"query": "query GetUser {"}`,
+		`{"user": "42"}`,
+	} {
+		if mock.Request.Match(post(body)) {
+			t.Errorf("request with body %s should not match", body)
+		}
+	}
+
+	report := mock.Request.Check(post(`{"query": "query GetUser { user { name } }", "variables": {"id": "42"}}`))
+	if len(report.Mismatches) != 2 || report.Mismatches[0].Field != "graphql.fields" || report.Mismatches[0].Actual != `["user"]` ||
+		report.Mismatches[1].Field != "graphql.variables.filter.active" {
+		t.Errorf("report = %+v", report)
+	}
+
+	// GraphQL requests may also be sent with GET, or as an application/graphql document.
+	get := httptest.NewRequest(http.MethodGet, "/graphql?"+url.Values{
+		"query":     {"{ user { name } }"},
+		"variables": {`{"id": "42"}`},
+	}.Encode(), nil)
+	if operation := HTTPRequestToRequest(get).GraphQL; operation == nil || operation.OperationType != "query" || operation.Variables["id"] != "42" {
+		t.Errorf("graphql = %+v", operation)
+	}
+	document := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("mutation { deleteUser(id: 1) }"))
+	document.Header.Set("Content-Type", "application/graphql")
+	if operation := HTTPRequestToRequest(document).GraphQL; operation == nil || operation.OperationType != "mutation" {
+		t.Errorf("graphql = %+v", operation)
+	}
+	if operation := HTTPRequestToRequest(httptest.NewRequest(http.MethodGet, "/search?query=books", nil)).GraphQL; operation != nil {
+		t.Errorf("graphql = %+v, want none", operation)
+	}
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,120 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const ContainJSONMatcherName = "ShouldContainJSON"
+
+// Array modes of ShouldContainJSON: with ordered, arrays must have as many items as expected, each
+// containing the expected item at the same position; with unordered, the items may come in any
+// order; with contains, arrays may also have other items.
+const (
+	JSONArraysOrdered   = "ordered"
+	JSONArraysUnordered = "unordered"
+	JSONArraysContains  = "contains"
+)
+
+var jsonArrayModes = map[string]bool{"": true, JSONArraysOrdered: true, JSONArraysUnordered: true, JSONArraysContains: true}
+
+// ShouldContainJSON matches a JSON document, or a value decoded from one, containing the expected
+// JSON document: objects must have the expected fields, recursively, and may have others. An
+// optional second expected value sets how arrays are compared, ordered by default.
+func ShouldContainJSON(value interface{}, expected ...interface{}) string {
+	if len(expected) == 0 || len(expected) > 2 {
+		return "ShouldContainJSON expects a JSON document and an optional array mode"
+	}
+	expectedString, ok := expected[0].(string)
+	if !ok {
+		return "ShouldContainJSON works only with strings"
+	}
+	var expectedJSON interface{}
+	if err := json.Unmarshal([]byte(expectedString), &expectedJSON); err != nil {
+		return fmt.Sprintf("ShouldContainJSON expects a JSON document: %v", err)
+	}
+	mode := JSONArraysOrdered
+	if len(expected) == 2 {
+		mode, ok = expected[1].(string)
+		if !ok || !jsonArrayModes[mode] {
+			return fmt.Sprintf("ShouldContainJSON expects arrays to be %q, %q or %q", JSONArraysOrdered, JSONArraysUnordered, JSONArraysContains)
+		}
+	}
+
+	var actual interface{}
+	if valueString, ok := value.(string); ok {
+		if err := json.Unmarshal([]byte(valueString), &actual); err != nil {
+			return fmt.Sprintf("Expected %q to be a JSON document (but it wasn't)!", valueString)
+		}
+	} else {
+		// Decoded values may hold other types than the ones of encoding/json, e.g. integers.
+		b, err := json.Marshal(value)
+		if err != nil || json.Unmarshal(b, &actual) != nil {
+			return fmt.Sprintf("Expected %v to be a JSON value (but it wasn't)!", value)
+		}
+	}
+	return containsJSON("$", actual, expectedJSON, mode)
+}
+
+func containsJSON(path string, actual, expected interface{}, mode string) string {
+	switch expectedValue := expected.(type) {
+	case map[string]interface{}:
+		actualObject, ok := actual.(map[string]interface{})
+		if !ok {
+			return fmt.Sprintf("Expected %s to be an object (but was %s)!", path, jsonText(actual))
+		}
+		keys := make([]string, 0, len(expectedValue))
+		for key := range expectedValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fieldPath := path + "." + key
+			actualField, ok := actualObject[key]
+			if !ok {
+				return fmt.Sprintf("Expected %s to be present (but it wasn't)!", fieldPath)
+			}
+			if message := containsJSON(fieldPath, actualField, expectedValue[key], mode); message != "" {
+				return message
+			}
+		}
+		return ""
+
+	case []interface{}:
+		actualArray, ok := actual.([]interface{})
+		if !ok {
+			return fmt.Sprintf("Expected %s to be an array (but was %s)!", path, jsonText(actual))
+		}
+		if mode != JSONArraysContains && len(actualArray) != len(expectedValue) {
+			return fmt.Sprintf("Expected %s to have %d items (but it has %d)!", path, len(expectedValue), len(actualArray))
+		}
+		if mode == JSONArraysOrdered {
+			for i := range expectedValue {
+				if message := containsJSON(fmt.Sprintf("%s[%d]", path, i), actualArray[i], expectedValue[i], mode); message != "" {
+					return message
+				}
+			}
+			return ""
+		}
+		matched := assignEach(len(expectedValue), len(actualArray), func(i, j int) bool {
+			return containsJSON(path, actualArray[j], expectedValue[i], mode) == ""
+		})
+		if matched < len(expectedValue) {
+			return fmt.Sprintf("Expected %s to contain an item matching %s (but it didn't)!", path, jsonText(expectedValue[matched]))
+		}
+		return ""
+
+	default:
+		if !reflect.DeepEqual(actual, expected) {
+			return fmt.Sprintf("Expected %s to be %s (but was %s)!", path, jsonText(expected), jsonText(actual))
+		}
+		return ""
+	}
+}
+
+func jsonText(value interface{}) string {
+	b, _ := json.Marshal(value)
+	return string(b)
+}
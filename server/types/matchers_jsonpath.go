@@ -0,0 +1,180 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/speakeasy-api/jsonpath/pkg/jsonpath"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	QuantifierAny = "any"
+	QuantifierAll = "all"
+)
+
+// JSONPathMatcher matches a JSON body through JSONPath expressions (RFC 9535), such as
+// "$.items[*].sku" or "$.items[?(@.qty > 1)]". As a path may select several values, each
+// condition states whether any (the default) or all of them must satisfy its matcher. A path
+// selecting nothing never matches.
+type JSONPathMatcher struct {
+	JSONPath map[string]JSONPathCondition `json:"json_path" yaml:"json_path"`
+
+	// compiled holds the expressions compiled by Validate.
+	compiled map[string]*jsonpath.JSONPath
+}
+
+type JSONPathCondition struct {
+	StringMatcher
+	Quantifier string
+}
+
+// Validate checks the conditions and compiles the expressions, once for all the requests to match.
+func (jm *JSONPathMatcher) Validate() error {
+	compiled := make(map[string]*jsonpath.JSONPath, len(jm.JSONPath))
+	for path, condition := range jm.JSONPath {
+		c, err := jsonpath.NewPath(path)
+		if err != nil {
+			return fmt.Errorf("invalid JSONPath expression %q: %v", path, err)
+		}
+		compiled[path] = c
+		if condition.Quantifier != "" && condition.Quantifier != QuantifierAny && condition.Quantifier != QuantifierAll {
+			return fmt.Errorf("invalid quantifier %q for JSONPath expression %q, expected %q or %q",
+				condition.Quantifier, path, QuantifierAny, QuantifierAll)
+		}
+	}
+	jm.compiled = compiled
+	return nil
+}
+
+func (jm JSONPathMatcher) Match(value string) bool {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		slog.Debug("Failed to parse request body as JSON", "error", err)
+		return false
+	}
+	var doc yaml.Node
+	if err := doc.Encode(decoded); err != nil {
+		slog.Error("Failed to convert request body for JSONPath evaluation", "error", err)
+		return false
+	}
+
+	for path, condition := range jm.JSONPath {
+		compiled := jm.compiled[path]
+		if compiled == nil {
+			// The matcher was built in code, without Validate.
+			var err error
+			if compiled, err = jsonpath.NewPath(path); err != nil {
+				slog.Error("Invalid JSONPath expression", "jsonpath", path, "error", err)
+				return false
+			}
+		}
+		if !condition.Match(compiled.Query(&doc)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (jc JSONPathCondition) Match(nodes []*yaml.Node) bool {
+	if len(nodes) == 0 {
+		return false
+	}
+	for _, node := range nodes {
+		matched := jc.StringMatcher.MatchValue(jsonPathNodeValue(node))
+		if jc.Quantifier == QuantifierAll && !matched {
+			return false
+		}
+		if jc.Quantifier != QuantifierAll && matched {
+			return true
+		}
+	}
+	return jc.Quantifier == QuantifierAll
+}
+
+// jsonPathNodeValue returns the decoded value of a selected node.
+func jsonPathNodeValue(node *yaml.Node) interface{} {
+	var v interface{}
+	if err := node.Decode(&v); err != nil {
+		return node.Value
+	}
+	return v
+}
+
+type jsonPathConditionSerialization struct {
+	Matcher    string `json:"matcher" yaml:"matcher,flow"`
+	Value      string `json:"value" yaml:"value,flow"`
+	Arrays     string `json:"arrays,omitempty" yaml:"arrays,omitempty"`
+	Quantifier string `json:"quantifier" yaml:"quantifier"`
+}
+
+type compositeJSONPathConditionSerialization struct {
+	compositeMatcherSerialization `yaml:",inline"`
+	Quantifier                    string `json:"quantifier" yaml:"quantifier"`
+}
+
+func (jc JSONPathCondition) serialization() interface{} {
+	if jc.Quantifier == "" {
+		return jc.StringMatcher
+	}
+	if jc.IsComposite() {
+		return compositeJSONPathConditionSerialization{
+			compositeMatcherSerialization: compositeMatcherSerialization{AnyOf: jc.AnyOf, AllOf: jc.AllOf, Not: jc.Not},
+			Quantifier:                    jc.Quantifier,
+		}
+	}
+	return jsonPathConditionSerialization{
+		Matcher:    jc.Matcher,
+		Value:      jc.Value,
+		Arrays:     jc.Arrays,
+		Quantifier: jc.Quantifier,
+	}
+}
+
+func (jc JSONPathCondition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jc.serialization())
+}
+
+func (jc *JSONPathCondition) UnmarshalJSON(data []byte) error {
+	var res struct {
+		Quantifier string `json:"quantifier"`
+	}
+	// A scalar shorthand has no quantifier; the matcher itself reports invalid input.
+	_ = json.Unmarshal(data, &res)
+
+	if err := json.Unmarshal(data, &jc.StringMatcher); err != nil {
+		return err
+	}
+	jc.Quantifier = res.Quantifier
+	return nil
+}
+
+func (jc JSONPathCondition) MarshalYAML() (interface{}, error) {
+	return jc.serialization(), nil
+}
+
+func (jc *JSONPathCondition) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var res struct {
+		Quantifier string `yaml:"quantifier"`
+	}
+	// A scalar shorthand has no quantifier; the matcher itself reports invalid input.
+	_ = unmarshal(&res)
+
+	if err := unmarshal(&jc.StringMatcher); err != nil {
+		return err
+	}
+	jc.Quantifier = res.Quantifier
+	return nil
+}
+
+// isJSONPathBody tells a JSONPath body matcher ({json_path: {...}}) apart from a map of JSON
+// paths, which may legitimately contain a "json_path" key holding a single matcher.
+func isJSONPathBody(raw map[string]interface{}) bool {
+	paths, ok := raw["json_path"].(map[string]interface{})
+	if !ok || len(raw) != 1 {
+		return false
+	}
+	_, isMatcher := paths["matcher"]
+	return !isMatcher
+}
@@ -0,0 +1,131 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+const JSONSchemaMatcherName = "ShouldMatchJSONSchema"
+
+// JSONSchemaMatcher matches a JSON body validating against an inline JSON Schema. The schema is
+// written either as an object or as a JSON string; it is compiled once, when the matcher is
+// unmarshaled, and Validate keeps the compiled schema. Mocks are self-contained, so the schema
+// cannot $ref external documents.
+type JSONSchemaMatcher struct {
+	Schema   interface{}
+	compiled *jsonschema.Schema
+}
+
+func (sm *JSONSchemaMatcher) Validate() error {
+	if sm.compiled != nil {
+		return nil
+	}
+	compiled, err := sm.compile()
+	if err != nil {
+		return err
+	}
+	sm.compiled = compiled
+	return nil
+}
+
+func (sm *JSONSchemaMatcher) compile() (*jsonschema.Schema, error) {
+	raw, ok := sm.Schema.(string)
+	if !ok {
+		b, err := json.Marshal(sm.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON schema provided to %q operator: %v", JSONSchemaMatcherName, err)
+		}
+		raw = string(b)
+	}
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema provided to %q operator: %v", JSONSchemaMatcherName, err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(jsonschema.SchemeURLLoader{})
+	if err := compiler.AddResource("body.schema.json", doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema provided to %q operator: %v", JSONSchemaMatcherName, err)
+	}
+	compiled, err := compiler.Compile("body.schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema provided to %q operator: %v", JSONSchemaMatcherName, err)
+	}
+	return compiled, nil
+}
+
+func (sm *JSONSchemaMatcher) Match(value string) bool {
+	return sm.Explain(value) == ""
+}
+
+// Explain returns why value doesn't validate against the schema, or an empty string when it does.
+func (sm *JSONSchemaMatcher) Explain(value string) string {
+	compiled := sm.compiled
+	if compiled == nil {
+		// The matcher was built in code, without Validate.
+		var err error
+		if compiled, err = sm.compile(); err != nil {
+			slog.Error("Invalid JSON schema", "error", err)
+			return err.Error()
+		}
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(strings.NewReader(value))
+	if err != nil {
+		slog.Debug("Failed to parse request body as JSON", "error", err)
+		return fmt.Sprintf("Expected body to be JSON (but it wasn't): %v", err)
+	}
+	if err := compiled.Validate(instance); err != nil {
+		slog.Debug(fmt.Sprintf("Value doesn't match:\n%v", err))
+		return err.Error()
+	}
+	return ""
+}
+
+type jsonSchemaMatcherSerialization struct {
+	Matcher string      `json:"matcher" yaml:"matcher,flow"`
+	Value   interface{} `json:"value" yaml:"value"`
+}
+
+func (sm JSONSchemaMatcher) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonSchemaMatcherSerialization{Matcher: JSONSchemaMatcherName, Value: sm.Schema})
+}
+
+func (sm JSONSchemaMatcher) MarshalYAML() (interface{}, error) {
+	return jsonSchemaMatcherSerialization{Matcher: JSONSchemaMatcherName, Value: sm.Schema}, nil
+}
+
+// isSchemaBody tells a JSON Schema body matcher ({matcher: ShouldMatchJSONSchema, value: ...})
+// apart from the other body matcher forms.
+func isSchemaBody(raw map[string]interface{}) bool {
+	if matcher, _ := raw["matcher"].(string); matcher != JSONSchemaMatcherName {
+		return false
+	}
+	for key := range raw {
+		if key != "matcher" && key != "value" {
+			return false
+		}
+	}
+	return true
+}
+
+// newJSONSchemaMatcher builds a schema matcher from the "value" of a decoded body matcher. The schema
+// is normalized through JSON, so that a schema written in YAML serializes like one written in JSON.
+func newJSONSchemaMatcher(raw map[string]interface{}) (*JSONSchemaMatcher, error) {
+	schema := raw["value"]
+	if _, ok := schema.(string); !ok {
+		b, err := json.Marshal(schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON schema provided to %q operator: %v", JSONSchemaMatcherName, err)
+		}
+		if err := json.Unmarshal(b, &schema); err != nil {
+			return nil, err
+		}
+	}
+	sm := &JSONSchemaMatcher{Schema: schema}
+	return sm, sm.Validate()
+}
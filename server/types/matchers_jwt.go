@@ -0,0 +1,162 @@
+package types
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/objx"
+)
+
+// JWTMatcher matches the JSON Web Token sent as a bearer token in the Authorization header. Its
+// header and claims are matched by path, like the fields of a JSON body, e.g. "realm_access.roles".
+// When a HMAC Secret or a PEM encoded PublicKey (RSA, ECDSA or Ed25519) is provided, the signature
+// of the token and its time claims (exp, nbf, iat) are verified too.
+type JWTMatcher struct {
+	Secret    string                   `json:"secret,omitempty" yaml:"secret,omitempty"`
+	PublicKey string                   `json:"public_key,omitempty" yaml:"public_key,omitempty"`
+	Header    map[string]StringMatcher `json:"header,omitempty" yaml:"header,omitempty"`
+	Claims    map[string]StringMatcher `json:"claims,omitempty" yaml:"claims,omitempty"`
+	publicKey crypto.PublicKey
+}
+
+// RequestJWT is the decoded, but not verified, JWT bearer token of a request.
+type RequestJWT struct {
+	Header map[string]interface{} `json:"header" yaml:"header"`
+	Claims map[string]interface{} `json:"claims" yaml:"claims"`
+}
+
+// Validate parses the public key of the matcher once, when the mock is registered or loaded.
+func (jm *JWTMatcher) Validate() error {
+	if jm.Secret != "" && jm.PublicKey != "" {
+		return errors.New("invalid jwt matcher: only one of secret and public_key can be provided")
+	}
+	if jm.PublicKey != "" {
+		publicKey, err := parsePublicKey(jm.PublicKey)
+		if err != nil {
+			return fmt.Errorf("invalid jwt public_key: %v", err)
+		}
+		jm.publicKey = publicKey
+	}
+	return nil
+}
+
+func (jm JWTMatcher) Match(headers http.Header) bool {
+	r := matchReporter{fast: true}
+	r.jwt(jm, headers)
+	return !r.failed()
+}
+
+// parse decodes token, and verifies it when the matcher has a key.
+func (jm JWTMatcher) parse(token string) (*jwt.Token, error) {
+	claims := jwt.MapClaims{}
+	if jm.Secret == "" && jm.PublicKey == "" {
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, claims)
+		return parsed, err
+	}
+
+	var (
+		key     interface{}
+		methods []string
+	)
+	if jm.Secret != "" {
+		key, methods = []byte(jm.Secret), []string{"HS256", "HS384", "HS512"}
+	} else {
+		// The public key is parsed by Validate, or now for a matcher built in code.
+		publicKey := jm.publicKey
+		if publicKey == nil {
+			var err error
+			if publicKey, err = parsePublicKey(jm.PublicKey); err != nil {
+				return nil, err
+			}
+		}
+		key = publicKey
+		switch publicKey.(type) {
+		case *rsa.PublicKey:
+			methods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+		case *ecdsa.PublicKey:
+			methods = []string{"ES256", "ES384", "ES512"}
+		case ed25519.PublicKey:
+			methods = []string{"EdDSA"}
+		}
+	}
+	return jwt.NewParser(jwt.WithValidMethods(methods)).ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return key, nil
+	})
+}
+
+func parsePublicKey(pemKey string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("expected a PEM encoded key")
+	}
+	var key crypto.PublicKey
+	if pkix, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		key = pkix
+	} else if pkcs1, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		key = pkcs1
+	} else if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		key = cert.PublicKey
+	} else {
+		return nil, errors.New("expected a PKIX or PKCS #1 public key, or a certificate")
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+	return key, nil
+}
+
+// bearerToken returns the bearer token of the Authorization header, if any.
+func bearerToken(headers http.Header) string {
+	scheme, token, found := strings.Cut(headers.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// decodeJWT decodes the JWT bearer token of a request, without verifying it, so that dynamic
+// responses can use its claims.
+func decodeJWT(headers http.Header) *RequestJWT {
+	token := bearerToken(headers)
+	if token == "" {
+		return nil
+	}
+	claims := jwt.MapClaims{}
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	if err != nil {
+		return nil
+	}
+	return &RequestJWT{Header: parsed.Header, Claims: claims}
+}
+
+func (r *matchReporter) jwt(jm JWTMatcher, headers http.Header) {
+	if r.done() {
+		return
+	}
+	token := bearerToken(headers)
+	if token == "" {
+		r.add(&Mismatch{Field: "jwt", Message: "Expected a bearer token in the Authorization header (but there was none)!"})
+		return
+	}
+	parsed, err := jm.parse(token)
+	if err != nil {
+		r.add(&Mismatch{Field: "jwt", Actual: token, Message: fmt.Sprintf("Expected a valid JWT (but it wasn't): %v", err)})
+		return
+	}
+	r.add(nil)
+
+	claims, _ := parsed.Claims.(jwt.MapClaims)
+	r.paths("jwt.header", jm.Header, objx.Map(parsed.Header))
+	r.paths("jwt.claims", jm.Claims, objx.Map(claims))
+}
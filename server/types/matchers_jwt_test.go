@@ -0,0 +1,122 @@
+package types
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gopkg.in/yaml.v3"
+)
+
+func TestJWTMatcher(t *testing.T) {
+	sign := func(method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) http.Header {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return http.Header{"Authorization": {"Bearer " + token}}
+	}
+	claims := jwt.MapClaims{
+		"sub":    "alice",
+		"scope":  "read write",
+		"tenant": map[string]interface{}{"id": "acme"},
+		"roles":  []string{"admin", "dev"},
+	}
+
+	var mock Mock
+	err := yaml.Unmarshal([]byte(`
+request:
+  path: /profile
+  jwt:
+    secret: s3cr3t
+    header:
+      alg: HS256
+    claims:
+      tenant.id: acme
+      scope: {matcher: ShouldContainSubstring, value: write}
+      roles: {matcher: ShouldContainJSON, value: [admin], arrays: contains}
+response:
+  status: 200
+`), &mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	jm := *mock.Request.JWT
+
+	if !jm.Match(sign(jwt.SigningMethodHS256, []byte("s3cr3t"), claims)) {
+		t.Error("a token with matching claims should match")
+	}
+	if jm.Match(sign(jwt.SigningMethodHS256, []byte("other"), claims)) {
+		t.Error("a token signed with another secret should not match")
+	}
+	if jm.Match(sign(jwt.SigningMethodHS256, []byte("s3cr3t"), jwt.MapClaims{"sub": "alice", "exp": time.Now().Add(-time.Hour).Unix()})) {
+		t.Error("an expired token should not match")
+	}
+	if jm.Match(http.Header{}) || jm.Match(http.Header{"Authorization": {"Basic YWxpY2U6"}}) {
+		t.Error("a request without bearer token should not match")
+	}
+
+	report := mock.Request.Check(Request{
+		Method:  http.MethodGet,
+		Path:    "/profile",
+		Headers: sign(jwt.SigningMethodHS256, []byte("s3cr3t"), jwt.MapClaims{"scope": "read", "tenant": map[string]interface{}{"id": "globex"}}),
+	})
+	var fields []string
+	for _, mismatch := range report.Mismatches {
+		fields = append(fields, fmt.Sprintf("%s=%s", mismatch.Field, mismatch.Actual))
+	}
+	if got, want := strings.Join(fields, " "), "jwt.claims.roles= jwt.claims.scope=read jwt.claims.tenant.id=globex"; got != want {
+		t.Errorf("mismatches = %s, want %s", got, want)
+	}
+
+	// Without key, the token is only decoded.
+	unverified := JWTMatcher{Claims: map[string]StringMatcher{"sub": {Matcher: "ShouldEqual", Value: "alice"}}}
+	if !unverified.Match(sign(jwt.SigningMethodHS256, []byte("any"), claims)) {
+		t.Error("an unverified token with matching claims should match")
+	}
+	if unverified.Match(http.Header{"Authorization": {"Bearer not-a-jwt"}}) {
+		t.Error("an invalid token should not match")
+	}
+
+	// Tokens can be verified with a public key.
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaMatcher := JWTMatcher{PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))}
+	if err := rsaMatcher.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if rsaMatcher.publicKey == nil {
+		t.Fatal("the public key should be parsed by Validate")
+	}
+	if !rsaMatcher.Match(sign(jwt.SigningMethodRS256, private, claims)) {
+		t.Error("a token signed with the private key should match")
+	}
+	if rsaMatcher.Match(sign(jwt.SigningMethodHS256, der, claims)) {
+		t.Error("a token signed with HMAC should not match a public key")
+	}
+
+	for _, invalid := range []JWTMatcher{
+		{Secret: "s3cr3t", PublicKey: rsaMatcher.PublicKey},
+		{PublicKey: "not a key"},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("%+v should be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+const CIDRMatcherName = "ShouldBeInCIDR"
+
+// ShouldBeInCIDR matches an IP address against a comma-separated list of CIDR ranges or single
+// addresses, e.g. "10.0.0.0/8, 192.168.1.10".
+func ShouldBeInCIDR(value interface{}, ranges ...interface{}) string {
+	valueString, ok := value.(string)
+	if !ok {
+		return "ShouldBeInCIDR works only with strings"
+	}
+	addr, err := netip.ParseAddr(valueString)
+	if err != nil {
+		return fmt.Sprintf("Expected %q to be an IP address (but it wasn't)!", valueString)
+	}
+
+	for _, r := range ranges {
+		rangesString, ok := r.(string)
+		if !ok {
+			return "ShouldBeInCIDR works only with strings"
+		}
+		prefixes, err := parsePrefixes(rangesString)
+		if err != nil {
+			return fmt.Sprintf("ShouldBeInCIDR %v", err)
+		}
+		matched := false
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr.Unmap()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Sprintf("Expected %q to be in %q (but it wasn't)!", valueString, rangesString)
+		}
+	}
+	return ""
+}
+
+func parsePrefixes(value string) ([]netip.Prefix, error) {
+	prefixes := []netip.Prefix{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("expects CIDR ranges or IP addresses, got %q", item)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("expects CIDR ranges or IP addresses, got %q", item)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// TLSMatcher matches the properties of the TLS connection of a request. A request received
+// without TLS never matches.
+type TLSMatcher struct {
+	ServerName    *StringMatcher `json:"server_name,omitempty" yaml:"server_name,omitempty"`
+	ClientSubject *StringMatcher `json:"client_subject,omitempty" yaml:"client_subject,omitempty"`
+}
+
+func (tm TLSMatcher) Match(tls *RequestTLS) bool {
+	r := matchReporter{fast: true}
+	r.tls(tm, tls)
+	return !r.failed()
+}
@@ -0,0 +1,151 @@
+package types
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const PathTemplateMatcherName = "ShouldMatchPathTemplate"
+
+var pathParamName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ShouldMatchPathTemplate matches a path against a template such as
+// "/users/{userId}/orders/{orderId:[0-9]+}". A parameter matches a single path segment unless a
+// regular expression is given after its name.
+func ShouldMatchPathTemplate(value interface{}, templates ...interface{}) string {
+	valueString, ok := value.(string)
+	if !ok {
+		return "ShouldMatchPathTemplate works only with strings"
+	}
+
+	for _, tmpl := range templates {
+		templateString, ok := tmpl.(string)
+		if !ok {
+			return "ShouldMatchPathTemplate works only with strings"
+		}
+
+		re, err := compilePathTemplate(templateString)
+		if err != nil {
+			return fmt.Sprintf("Expected %q to match path template %q (but it didn't)!", valueString, templateString)
+		}
+		if message := explainRegexp(PathTemplateMatcherName, re, templateString, valueString); message != "" {
+			return message
+		}
+	}
+
+	return ""
+}
+
+// compilePathTemplate converts a path template to an anchored regular expression, with a named
+// capture group for each parameter.
+func compilePathTemplate(template string) (*regexp.Regexp, error) {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	names := map[string]bool{}
+	for rest := template; rest != ""; {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			pattern.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		if rest[start] == '}' {
+			return nil, fmt.Errorf("unexpected '}' in path template %q", template)
+		}
+		pattern.WriteString(regexp.QuoteMeta(rest[:start]))
+
+		// Braces may be nested in the regular expression of a parameter, e.g. {year:[0-9]{4}}.
+		end, depth := -1, 0
+		for i := start; i < len(rest) && end < 0; i++ {
+			switch rest[i] {
+			case '{':
+				depth++
+			case '}':
+				if depth--; depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed '{' in path template %q", template)
+		}
+
+		name, expr, hasExpr := strings.Cut(rest[start+1:end], ":")
+		if !pathParamName.MatchString(name) {
+			return nil, fmt.Errorf("invalid parameter name %q in path template %q", name, template)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicate parameter %q in path template %q", name, template)
+		}
+		names[name] = true
+		if !hasExpr {
+			expr = "[^/]+"
+		}
+		if _, err := regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("invalid regular expression for parameter %q in path template %q: %v", name, template, err)
+		}
+		fmt.Fprintf(&pattern, "(?P<%s>%s)", name, expr)
+		rest = rest[end+1:]
+	}
+	pattern.WriteString("$")
+	return regexp.Compile(pattern.String())
+}
+
+// Captures returns the parameters captured from value by the parameters of a
+// ShouldMatchPathTemplate template or by the named groups of a ShouldMatch regular expression.
+// A composite matcher returns the captures of its matching matchers. As request paths are kept
+// escaped, the captures are unescaped.
+func (sm StringMatcher) Captures(value string) map[string]string {
+	switch {
+	case sm.AnyOf != nil:
+		for _, child := range sm.AnyOf {
+			if child.Match(value) {
+				return child.Captures(value)
+			}
+		}
+		return nil
+	case sm.AllOf != nil:
+		var captures map[string]string
+		for _, child := range sm.AllOf {
+			for name, capture := range child.Captures(value) {
+				if captures == nil {
+					captures = map[string]string{}
+				}
+				captures[name] = capture
+			}
+		}
+		return captures
+	case sm.Not != nil:
+		return nil
+	}
+
+	if sm.Matcher != PathTemplateMatcherName && sm.Matcher != "ShouldMatch" {
+		return nil
+	}
+	re, err := sm.compiledRegexp()
+	if err != nil {
+		return nil
+	}
+
+	indexes := re.FindStringSubmatchIndex(value)
+	if indexes == nil {
+		return nil
+	}
+	var captures map[string]string
+	for i, name := range re.SubexpNames() {
+		// Optional groups which did not participate in the match are left out.
+		if name == "" || indexes[2*i] < 0 {
+			continue
+		}
+		if captures == nil {
+			captures = map[string]string{}
+		}
+		capture := value[indexes[2*i]:indexes[2*i+1]]
+		if unescaped, err := url.PathUnescape(capture); err == nil {
+			capture = unescaped
+		}
+		captures[name] = capture
+	}
+	return captures
+}
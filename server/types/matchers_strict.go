@@ -0,0 +1,186 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Strictness tells how the query parameters, headers or cookies of a request must match the ones a
+// mock declares. By default, only the declared keys are checked and a request may send others. In
+// strict mode, keys which are not declared fail, and each key must have as many values as declared
+// matchers, matched in any order or, in ordered mode, one by one.
+type Strictness int
+
+const (
+	NotStrict Strictness = iota
+	Strict
+	StrictOrdered
+)
+
+const strictOrderedValue = "ordered"
+
+// strictIgnoredHeaders are the headers which strict mode doesn't require to declare: Host, which
+// Smocker adds to every request itself, and the transport-level headers HTTP clients add on their
+// own. They are still matched when a mock declares them.
+var strictIgnoredHeaders = map[string]bool{
+	"Host":            true,
+	"User-Agent":      true,
+	"Accept-Encoding": true,
+	"Content-Length":  true,
+	"Connection":      true,
+}
+
+func (s Strictness) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.value())
+}
+
+func (s Strictness) MarshalYAML() (interface{}, error) {
+	return s.value(), nil
+}
+
+func (s Strictness) value() interface{} {
+	if s == StrictOrdered {
+		return strictOrderedValue
+	}
+	return s == Strict
+}
+
+func (s *Strictness) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return s.parse(value)
+}
+
+func (s *Strictness) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value interface{}
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return s.parse(value)
+}
+
+func (s *Strictness) parse(value interface{}) error {
+	switch value {
+	case nil, false:
+		*s = NotStrict
+	case true:
+		*s = Strict
+	case strictOrderedValue:
+		*s = StrictOrdered
+	default:
+		return fmt.Errorf("invalid strict mode %v, expected true, false or %q", value, strictOrderedValue)
+	}
+	return nil
+}
+
+// ExplainStrict is like Explain, but values must have as many values as matchers. Each matcher
+// must be satisfied by a different value, or by the value at the same position when ordered.
+func (sms StringMatcherSlice) ExplainStrict(values []string, ordered bool) string {
+	if len(sms) != len(values) {
+		return fmt.Sprintf("Expected %d values (but got %d)!", len(sms), len(values))
+	}
+	if ordered {
+		for i, matcher := range sms {
+			if message := matcher.Explain(values[i]); message != "" {
+				return message
+			}
+		}
+		return ""
+	}
+
+	matched := assignEach(len(sms), len(values), func(i, j int) bool {
+		return sms[i].Explain(values[j]) == ""
+	})
+	if matched < len(sms) {
+		return fmt.Sprintf("Expected values %q to satisfy each of the matchers (but they didn't)!", values)
+	}
+	return ""
+}
+
+// assignEach assigns a different item to each of the n expectations, among m items, where
+// satisfies tells whether item j satisfies expectation i. It returns the number of expectations
+// assigned before the first one that couldn't be, n when they all are.
+func assignEach(n, m int, satisfies func(i, j int) bool) int {
+	results := make([][]bool, n)
+	for i := range results {
+		results[i] = make([]bool, m)
+		for j := range results[i] {
+			results[i][j] = satisfies(i, j)
+		}
+	}
+
+	// Look for an item for each expectation, moving the items already assigned to other
+	// expectations when needed (augmenting paths of a bipartite matching).
+	assigned := make([]int, m)
+	for j := range assigned {
+		assigned[j] = -1
+	}
+	var assign func(i int, visited []bool) bool
+	assign = func(i int, visited []bool) bool {
+		for j := 0; j < m; j++ {
+			if !results[i][j] || visited[j] {
+				continue
+			}
+			visited[j] = true
+			if assigned[j] == -1 || assign(assigned[j], visited) {
+				assigned[j] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if !assign(i, make([]bool, m)) {
+			return i
+		}
+	}
+	return n
+}
+
+// undeclaredKeys returns the keys of actual which mmm doesn't declare, sorted. With headers, keys
+// are compared case-insensitively.
+func undeclaredKeys(mmm MultiMapMatcher, actual map[string][]string, headers bool) []string {
+	declared := map[string]bool{}
+	for key := range mmm {
+		if headers {
+			key = http.CanonicalHeaderKey(key)
+		}
+		declared[key] = true
+	}
+	undeclared := []string{}
+	for key := range actual {
+		if headers {
+			key = http.CanonicalHeaderKey(key)
+			if strictIgnoredHeaders[key] {
+				continue
+			}
+		}
+		if !declared[key] {
+			undeclared = append(undeclared, key)
+		}
+	}
+	sort.Strings(undeclared)
+	return undeclared
+}
+
+func (r *matchReporter) undeclared(field string, mmm MultiMapMatcher, actual map[string][]string, headers bool) {
+	if r.done() {
+		return
+	}
+	keys := undeclaredKeys(mmm, actual, headers)
+	if len(keys) == 0 {
+		r.add(nil)
+		return
+	}
+	r.add(&Mismatch{
+		Field:   field,
+		Matcher: "strict",
+		Actual:  strings.Join(keys, ", "),
+		Message: fmt.Sprintf("Expected no undeclared %s (but got %s)!", field, strings.Join(keys, ", ")),
+	})
+}
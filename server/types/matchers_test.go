@@ -1,9 +1,18 @@
 package types
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"fmt"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
+	"net/textproto"
 	"reflect"
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v3"
@@ -132,6 +141,212 @@ func TestStringMatcherYAML(t *testing.T) {
 	}
 }
 
+func TestCompositeStringMatcher(t *testing.T) {
+	var mock Mock
+	err := yaml.Unmarshal([]byte(`
+request:
+  method: {any_of: [GET, HEAD]}
+  path: {not: {matcher: ShouldStartWith, value: /admin}}
+  headers:
+    Accept: {any_of: [application/json, {matcher: ShouldEndWith, value: +json}]}
+  body:
+    status: {not: {any_of: [deleted, archived]}}
+    age: {all_of: [{matcher: ShouldBeGreaterThan, value: "17"}, {matcher: ShouldBeLessThan, value: "65"}]}
+response:
+  status: 200
+`), &mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !mock.Request.Path.IsComposite() {
+		t.Fatal("Validate should keep a composite path matcher")
+	}
+
+	request := func(method, path, accept, body string) Request {
+		return Request{
+			Method:     method,
+			Path:       path,
+			Headers:    http.Header{"Accept": {accept}},
+			BodyString: body,
+		}
+	}
+	cases := []struct {
+		name    string
+		request Request
+		match   bool
+	}{
+		{"all matching", request("HEAD", "/users", "application/vnd.api+json", `{"status": "active", "age": 30}`), true},
+		{"method in none", request("POST", "/users", "application/json", `{"status": "active", "age": 30}`), false},
+		{"negated path", request("GET", "/admin/users", "application/json", `{"status": "active", "age": 30}`), false},
+		{"header in none", request("GET", "/users", "text/html", `{"status": "active", "age": 30}`), false},
+		{"negated body field", request("GET", "/users", "application/json", `{"status": "archived", "age": 30}`), false},
+		{"body field out of all", request("GET", "/users", "application/json", `{"status": "active", "age": 70}`), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := mock.Request.Match(c.request); got != c.match {
+				t.Fatalf("match = %v, want %v", got, c.match)
+			}
+		})
+	}
+
+	// Composite nodes serialize without the leaf fields, and the scalar shorthand still loads.
+	b, err := json.Marshal(mock.Request.Method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"any_of":[{"matcher":"ShouldEqual","value":"GET"},{"matcher":"ShouldEqual","value":"HEAD"}]}`
+	if string(b) != want {
+		t.Fatalf("serialized value %s should be equal to %s", b, want)
+	}
+	var res StringMatcher
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, mock.Request.Method) {
+		t.Fatalf("%+v should round-trip to %+v", res, mock.Request.Method)
+	}
+
+	// A whole-body composite matcher uses any_of or all_of; "not" stays a JSON field name.
+	var bm BodyMatcher
+	if err := json.Unmarshal([]byte(`{"any_of": [{"matcher": "ShouldContainSubstring", "value": "foo"}, "bar"]}`), &bm); err != nil {
+		t.Fatal(err)
+	}
+	if bm.bodyString == nil || !bm.Match(http.Header{}, "a foo") || bm.Match(http.Header{}, "baz") {
+		t.Fatal("body should be matched as a whole by any_of")
+	}
+	bm = BodyMatcher{}
+	if err := json.Unmarshal([]byte(`{"not": "x"}`), &bm); err != nil {
+		t.Fatal(err)
+	}
+	if bm.bodyJson == nil || !bm.Match(http.Header{}, `{"not": "x"}`) {
+		t.Fatal(`"not" should be matched as a JSON field of the body`)
+	}
+
+	for _, invalid := range []string{
+		`{"any_of": []}`,
+		`{"matcher": "ShouldEqual", "value": "x", "not": "y"}`,
+		`{"any_of": ["x"], "all_of": ["y"]}`,
+		`{"not": {"matcher": "ShouldBeAwesome"}}`,
+	} {
+		if err := json.Unmarshal([]byte(invalid), &StringMatcher{}); err == nil {
+			t.Errorf("%s should be rejected", invalid)
+		}
+	}
+}
+
+func TestPathTemplateMatcher(t *testing.T) {
+	cases := []struct {
+		template string
+		path     string
+		captures map[string]string
+	}{
+		{"/users/{userId}/orders/{orderId:[0-9]+}", "/users/u-1/orders/42", map[string]string{"userId": "u-1", "orderId": "42"}},
+		{"/users/{userId}/orders/{orderId:[0-9]+}", "/users/u-1/orders/last", nil},
+		{"/users/{userId}", "/users/u-1/orders", nil},
+		{"/archive/{year:[0-9]{4}}/{rest:.*}", "/archive/2024/a/b.txt", map[string]string{"year": "2024", "rest": "a/b.txt"}},
+		{"/files/{name}.json", "/files/report.json", map[string]string{"name": "report"}},
+		{"/files/{name}.json", "/files/report-json", nil},
+		{"/users/{userId}/orders/{orderId}", "/users/jane%20doe/orders/a%2Fb", map[string]string{"userId": "jane doe", "orderId": "a/b"}},
+	}
+	for _, c := range cases {
+		matcher := StringMatcher{Matcher: PathTemplateMatcherName, Value: c.template}
+		if err := matcher.Validate(); err != nil {
+			t.Fatalf("%s: %v", c.template, err)
+		}
+		if got := matcher.Match(c.path); got != (c.captures != nil) {
+			t.Errorf("%s on %s: match = %v", c.template, c.path, got)
+		}
+		if got := matcher.Captures(c.path); !reflect.DeepEqual(got, c.captures) {
+			t.Errorf("%s on %s: captures = %v, want %v", c.template, c.path, got, c.captures)
+		}
+	}
+
+	// Named groups of ShouldMatch regular expressions are captured as well, also within composites.
+	var matcher StringMatcher
+	if err := yaml.Unmarshal([]byte(`any_of: [{matcher: ShouldMatch, value: "^/v1/items/(?P<id>[a-z]+)(/(?P<sub>[a-z]+))?$"}, /health]`), &matcher); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := matcher.Captures("/v1/items/abc"), map[string]string{"id": "abc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("captures = %v, want %v", got, want)
+	}
+	if got := matcher.Captures("/health"); got != nil {
+		t.Errorf("captures = %v, want none", got)
+	}
+
+	for _, invalid := range []string{"/users/{id", "/users/id}", "/users/{}", "/users/{id}/{id}", "/users/{id:[0-9}"} {
+		if err := (&StringMatcher{Matcher: PathTemplateMatcherName, Value: invalid}).Validate(); err == nil {
+			t.Errorf("%s should be rejected", invalid)
+		}
+	}
+}
+
+func TestConnectionMatchers(t *testing.T) {
+	var mock Mock
+	err := yaml.Unmarshal([]byte(`
+request:
+  path: /
+  origin: {matcher: ShouldBeInCIDR, value: "10.0.0.0/8, 192.168.1.10, 2001:db8::/32"}
+  host: api.example.com
+  scheme: https
+  tls:
+    server_name: api.example.com
+    client_subject: {matcher: ShouldContainSubstring, value: "O=Acme"}
+response:
+  status: 200
+`), &mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(target, remoteAddr string, clientCert bool) Request {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = remoteAddr
+		if req.TLS != nil && clientCert {
+			req.TLS.PeerCertificates = []*x509.Certificate{{
+				Subject: pkix.Name{CommonName: "billing", Organization: []string{"Acme"}},
+			}}
+		}
+		return HTTPRequestToRequest(req)
+	}
+
+	req := request("https://api.example.com/", "10.1.2.3:5555", true)
+	if req.Scheme != "https" || req.TLS == nil || req.TLS.ClientSubject != "CN=billing,O=Acme" {
+		t.Fatalf("unexpected connection properties: %+v %+v", req, req.TLS)
+	}
+	if host := request("http://api.example.com:8080/", "10.1.2.3:5555", false).Host; host != "api.example.com" {
+		t.Fatalf("host %q should be recorded without port", host)
+	}
+	if !mock.Request.Match(req) {
+		t.Error("request should match")
+	}
+	if !mock.Request.Match(request("https://api.example.com/", "[2001:db8::1]:5555", true)) {
+		t.Error("IPv6 origin in range should match")
+	}
+	if mock.Request.Match(request("https://api.example.com/", "192.168.1.11:5555", true)) {
+		t.Error("origin out of range should not match")
+	}
+	if mock.Request.Match(request("https://www.example.com/", "10.1.2.3:5555", true)) {
+		t.Error("another host should not match")
+	}
+	if mock.Request.Match(request("https://api.example.com/", "10.1.2.3:5555", false)) {
+		t.Error("request without client certificate should not match")
+	}
+	if mock.Request.Match(request("http://api.example.com/", "10.1.2.3:5555", false)) {
+		t.Error("plain HTTP request should not match")
+	}
+
+	if err := json.Unmarshal([]byte(`{"matcher": "ShouldBeInCIDR", "value": "10.0.0.0/33"}`), &StringMatcher{}); err == nil {
+		t.Error("invalid CIDR range should be rejected")
+	}
+}
+
 func TestMultiMapMatcherJSON(t *testing.T) {
 	test := `{"test":"test"}`
 	serialized := `{"test":[{"matcher":"ShouldEqual","value":"test"}]}`
@@ -198,3 +413,533 @@ func TestMultiMapMatcherJSON(t *testing.T) {
 		t.Fatalf("serialized value %s should be equal to %s", string(b), test)
 	}
 }
+
+const soapQuote = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:q="urn:quotes">
+  <soap:Body>
+    <q:GetQuote currency="USD">
+      <q:Symbol>ACME</q:Symbol>
+      <q:Symbol>INIT</q:Symbol>
+    </q:GetQuote>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestXPathBodyMatcher(t *testing.T) {
+	y := `
+xpath:
+  //soap:Body/quote:GetQuote/quote:Symbol: INIT
+  //quote:GetQuote/@currency:
+    matcher: ShouldStartWith
+    value: US
+  count(//quote:Symbol): "2"
+namespaces:
+  soap: http://schemas.xmlsoap.org/soap/envelope/
+  quote: urn:quotes
+`
+	var bm BodyMatcher
+	if err := yaml.Unmarshal([]byte(y), &bm); err != nil {
+		t.Fatal(err)
+	}
+	if bm.bodyXPath == nil {
+		t.Fatal("body matcher should be parsed as an XPath matcher")
+	}
+	if !bm.Match(http.Header{}, soapQuote) {
+		t.Error("XPath body matcher should match the SOAP envelope")
+	}
+	if bm.Match(http.Header{}, strings.Replace(soapQuote, "INIT", "OTHER", 1)) {
+		t.Error("XPath body matcher should not match when no selected node satisfies the matcher")
+	}
+	if bm.Match(http.Header{}, `{"not": "xml"}`) {
+		t.Error("XPath body matcher should not match a non-XML body")
+	}
+
+	// The XPath form round-trips through JSON.
+	b, err := json.Marshal(bm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res BodyMatcher
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatal(err)
+	}
+	// The compiled expressions hold functions, which can't be compared: the matchers are compared
+	// through their serialization.
+	if rb, _ := json.Marshal(res); string(rb) != string(b) || !reflect.DeepEqual(res.bodyXPath.XPath, bm.bodyXPath.XPath) {
+		t.Fatalf("JSON round-trip changed the matcher: %s", b)
+	}
+
+	// An invalid expression is rejected when the mock is loaded.
+	if err := yaml.Unmarshal([]byte("xpath: {'//[': foo}"), &BodyMatcher{}); err == nil {
+		t.Error("an invalid XPath expression should be rejected")
+	}
+
+	// A JSON path named "xpath" keeps its meaning.
+	var jsonBody BodyMatcher
+	if err := yaml.Unmarshal([]byte("xpath: {matcher: ShouldEqual, value: foo}"), &jsonBody); err != nil {
+		t.Fatal(err)
+	}
+	if jsonBody.bodyJson == nil || !jsonBody.Match(http.Header{}, `{"xpath": "foo"}`) {
+		t.Error(`a "xpath" JSON key holding a matcher should stay a JSON body matcher`)
+	}
+}
+
+func TestJSONSchemaBodyMatcher(t *testing.T) {
+	y := `
+request:
+  path: /orders
+  body:
+    matcher: ShouldMatchJSONSchema
+    value:
+      type: object
+      required: [id, items]
+      properties:
+        id: {type: integer, minimum: 1}
+        items: {type: array, minItems: 1}
+response:
+  status: 201
+`
+	var mock Mock
+	if err := yaml.Unmarshal([]byte(y), &mock); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	body := mock.Request.Body
+	if body.bodySchema == nil || body.bodySchema.compiled == nil {
+		t.Fatal("the JSON schema should be compiled by Mock.Validate")
+	}
+	if !body.Match(http.Header{}, `{"id": 3, "items": ["a"]}`) {
+		t.Error("a body valid against the schema should match")
+	}
+	if body.Match(http.Header{}, `{"id": 0, "items": ["a"]}`) {
+		t.Error("a body invalid against the schema should not match")
+	}
+	if body.Match(http.Header{}, `not json`) {
+		t.Error("a non-JSON body should not match")
+	}
+
+	// The schema round-trips through JSON, and may also be given as a JSON string.
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"matcher":"ShouldMatchJSONSchema","value":{"properties":{"id":{"minimum":1,"type":"integer"},` +
+		`"items":{"minItems":1,"type":"array"}},"required":["id","items"],"type":"object"}}`
+	if string(b) != want {
+		t.Fatalf("serialized value %s should be equal to %s", b, want)
+	}
+	var res BodyMatcher
+	if err := json.Unmarshal([]byte(`{"matcher":"ShouldMatchJSONSchema","value":"{\"type\": \"string\"}"}`), &res); err != nil {
+		t.Fatal(err)
+	}
+	// Mocks restored from persistence or imported sessions are unmarshaled without Mock.Validate.
+	if res.bodySchema == nil || res.bodySchema.compiled == nil {
+		t.Fatal("the JSON schema should be compiled when it is unmarshaled")
+	}
+	compiled := res.bodySchema.compiled
+	if err := res.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if res.bodySchema.compiled != compiled {
+		t.Error("the JSON schema should not be compiled again by Validate")
+	}
+	if !res.Match(http.Header{}, `"text"`) || res.Match(http.Header{}, `12`) {
+		t.Error("a schema given as a JSON string should be applied")
+	}
+
+	// Invalid schemas and external references are rejected at validation time.
+	for _, schema := range []string{`{"type": 12}`, `{"$ref": "https://example.com/schema.json"}`} {
+		invalid := NewJSONSchemaBodyMatcher(schema)
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("schema %s should be rejected", schema)
+		}
+	}
+}
+
+func TestJSONPathBodyMatcher(t *testing.T) {
+	order := `{"id": "o-1", "items": [{"sku": "A-1", "qty": 1}, {"sku": "B-2", "qty": 3}]}`
+
+	cases := []struct {
+		name    string
+		matcher string
+		match   bool
+	}{
+		{"wildcard, any element", `json_path: {"$.items[*].sku": B-2}`, true},
+		{"wildcard, no element", `json_path: {"$.items[*].sku": C-3}`, false},
+		{"filter", `json_path: {"$.items[?(@.qty > 1)].sku": B-2}`, true},
+		{"filter selecting nothing", `json_path: {"$.items[?(@.qty > 5)].sku": {matcher: ShouldNotBeEmpty}}`, false},
+		{"all elements", `json_path: {"$.items[*].sku": {matcher: ShouldContainSubstring, value: "-", quantifier: all}}`, true},
+		{"not all elements", `json_path: {"$.items[*].sku": {matcher: ShouldStartWith, value: A, quantifier: all}}`, false},
+		{"array as JSON", `json_path: {"$.items[0]": {matcher: ShouldEqualJSON, value: '{"qty": 1, "sku": "A-1"}'}}`, true},
+		{"number", `json_path: {"$.items[1].qty": "3"}`, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var bm BodyMatcher
+			if err := yaml.Unmarshal([]byte(c.matcher), &bm); err != nil {
+				t.Fatal(err)
+			}
+			if bm.bodyPath == nil {
+				t.Fatal("body matcher should be parsed as a JSONPath matcher")
+			}
+			if got := bm.Match(http.Header{}, order); got != c.match {
+				t.Fatalf("match = %v, want %v", got, c.match)
+			}
+		})
+	}
+
+	// The quantifier round-trips through JSON, and is omitted when not set.
+	var bm BodyMatcher
+	if err := yaml.Unmarshal([]byte(`json_path: {"$.a": x, "$.b[*]": {matcher: ShouldEqual, value: y, quantifier: all}}`), &bm); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(bm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"json_path":{"$.a":{"matcher":"ShouldEqual","value":"x"},` +
+		`"$.b[*]":{"matcher":"ShouldEqual","value":"y","quantifier":"all"}}}`
+	if string(b) != want {
+		t.Fatalf("serialized value %s should be equal to %s", b, want)
+	}
+
+	for _, invalid := range []string{`json_path: {"$.items[": x}`, `json_path: {"$.a": {matcher: ShouldEqual, value: x, quantifier: most}}`} {
+		if err := yaml.Unmarshal([]byte(invalid), &BodyMatcher{}); err == nil {
+			t.Errorf("%s should be rejected", invalid)
+		}
+	}
+}
+
+func multipartBody(t *testing.T) (http.Header, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.WriteField("title", "Q3 report"); err != nil {
+		t.Fatal(err)
+	}
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", `form-data; name="document"; filename="report.pdf"`)
+	h.Set("Content-Type", "application/pdf")
+	part, err := w.CreatePart(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write([]byte("%PDF-1.4")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return http.Header{"Content-Type": {w.FormDataContentType()}}, buf.String()
+}
+
+func TestTypedBodyMatcher(t *testing.T) {
+	body := `{"age": 42, "price": 9.5, "name": "alice", "admin": true, "manager": null, "tags": ["a", "b"], "address": {"city": "Paris"}}`
+
+	cases := []struct {
+		name    string
+		matcher string
+		match   bool
+	}{
+		{"greater than", `{"age": {"matcher": "ShouldBeGreaterThan", "value": 18}}`, true},
+		{"not greater than", `{"age": {"matcher": "ShouldBeGreaterThan", "value": "42"}}`, false},
+		{"less than or equal", `{"price": {"matcher": "ShouldBeLessThanOrEqualTo", "value": 9.5}}`, true},
+		{"between", `{"age": {"matcher": "ShouldBeBetween", "value": "40,50"}}`, true},
+		{"not between", `{"price": {"matcher": "ShouldBeBetween", "value": "10, 20"}}`, false},
+		{"number on a string", `{"name": {"matcher": "ShouldBeGreaterThan", "value": 1}}`, false},
+		{"length of an array", `{"tags": {"matcher": "ShouldHaveLength", "value": 2}}`, true},
+		{"length of a string", `{"name": {"matcher": "ShouldHaveLength", "value": 5}}`, true},
+		{"true", `{"admin": {"matcher": "ShouldBeTrue"}}`, true},
+		{"false", `{"admin": {"matcher": "ShouldBeFalse"}}`, false},
+		{"null", `{"manager": {"matcher": "ShouldBeNull"}}`, true},
+		{"missing field is null", `{"team": {"matcher": "ShouldBeNull"}}`, true},
+		{"not null", `{"name": {"matcher": "ShouldNotBeNull"}}`, true},
+		{"string", `{"name": {"matcher": "ShouldBeString"}}`, true},
+		{"number", `{"age": {"matcher": "ShouldBeNumber"}}`, true},
+		{"numeric string is not a number", `{"name": {"matcher": "ShouldBeNumber"}}`, false},
+		{"boolean", `{"admin": {"matcher": "ShouldBeBoolean"}}`, true},
+		{"array", `{"tags": {"matcher": "ShouldBeArray"}}`, true},
+		{"object", `{"address": {"matcher": "ShouldBeObject"}}`, true},
+		{"almost equal", `{"price": {"matcher": "ShouldAlmostEqual", "value": "9.5"}}`, true},
+		{"string matchers keep the string form", `{"admin": "true", "age": "42"}`, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var bm BodyMatcher
+			if err := json.Unmarshal([]byte(c.matcher), &bm); err != nil {
+				t.Fatal(err)
+			}
+			if got := bm.Match(http.Header{}, body); got != c.match {
+				t.Fatalf("match = %v, want %v", got, c.match)
+			}
+		})
+	}
+
+	// Typed matchers also apply to string values, such as query parameters and JSONPath results.
+	if !(StringMatcher{Matcher: "ShouldBeLessThan", Value: "10"}).Match("9") {
+		t.Error("9 should be less than 10")
+	}
+	var bm BodyMatcher
+	if err := yaml.Unmarshal([]byte(`json_path: {"$.tags": {matcher: ShouldHaveLength, value: 2}, "$.age": {matcher: ShouldBeNumber}}`), &bm); err != nil {
+		t.Fatal(err)
+	}
+	if !bm.Match(http.Header{}, body) {
+		t.Error("JSONPath matchers should receive the decoded values")
+	}
+
+	for _, invalid := range []string{
+		`{"matcher": "ShouldBeGreaterThan", "value": "ten"}`,
+		`{"matcher": "ShouldBeBetween", "value": "10"}`,
+		`{"matcher": "ShouldBeBetween", "value": "20,10"}`,
+		`{"matcher": "ShouldHaveLength", "value": -1}`,
+		`{"matcher": "ShouldHaveLength", "value": [1]}`,
+	} {
+		if err := json.Unmarshal([]byte(invalid), &StringMatcher{}); err == nil {
+			t.Errorf("%s should be rejected", invalid)
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"matcher": "ShouldAlmostEqual", "value": "ten"}`), &StringMatcher{}); err != nil {
+		t.Errorf("ShouldAlmostEqual should keep accepting any value: %v", err)
+	}
+}
+
+func TestMultipartBodyMatcher(t *testing.T) {
+	headers, body := multipartBody(t)
+
+	form, err := ParseMultipartForm(headers, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &MultipartForm{
+		Fields: map[string][]string{"title": {"Q3 report"}},
+		Files: map[string][]MultipartFile{"document": {{
+			Filename:    "report.pdf",
+			ContentType: "application/pdf",
+			Size:        8,
+			SHA256:      fmt.Sprintf("%x", sha256.Sum256([]byte("%PDF-1.4"))),
+		}}},
+	}
+	if !reflect.DeepEqual(form, want) {
+		t.Fatalf("parsed form %+v should be equal to %+v", form, want)
+	}
+
+	for _, y := range []string{
+		`{"fields.title[0]": Q3 report, "files.document[0].filename": {matcher: ShouldEndWith, value: .pdf}}`,
+		`json_path: {"$.files.document[*].content_type": application/pdf}`,
+	} {
+		var bm BodyMatcher
+		if err := yaml.Unmarshal([]byte(y), &bm); err != nil {
+			t.Fatal(err)
+		}
+		if !bm.Match(headers, body) {
+			t.Errorf("%s should match the multipart body", y)
+		}
+		// The form already parsed from the request is used instead of the body.
+		r := matchReporter{fast: true}
+		r.body(bm, headers, "", form)
+		if r.failed() {
+			t.Errorf("%s should match the parsed multipart form", y)
+		}
+	}
+
+	if form, err := ParseMultipartForm(http.Header{"Content-Type": {"application/json"}}, `{}`); form != nil || err != nil {
+		t.Errorf("a non-multipart body should not be parsed, got %+v, %v", form, err)
+	}
+}
+
+func TestStrictMultiMapMatchers(t *testing.T) {
+	var mock Mock
+	err := yaml.Unmarshal([]byte(`
+request:
+  path: /search
+  query_params:
+    q: books
+    tag: [{matcher: ShouldStartWith, value: a}, {matcher: ShouldStartWith, value: ab}]
+  query_params_strict: true
+  headers:
+    content-type: application/json
+  headers_strict: true
+response:
+  status: 200
+`), &mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(query string, headers http.Header) Request {
+		req := httptest.NewRequest(http.MethodGet, "/search?"+query, nil)
+		req.Header = headers
+		return HTTPRequestToRequest(req)
+	}
+	jsonHeaders := http.Header{"Content-Type": {"application/json"}}
+
+	// The values of a key are matched in any order, each by a different matcher.
+	if !mock.Request.Match(request("q=books&tag=abc&tag=az", jsonHeaders)) {
+		t.Error("request with the declared keys should match")
+	}
+	for _, query := range []string{"q=books&tag=abc&tag=az&debug=1", "q=books&tag=abc&tag=az&tag=ab", "q=books&tag=az&tag=ac"} {
+		if mock.Request.Match(request(query, jsonHeaders)) {
+			t.Errorf("request with query %q should not match", query)
+		}
+	}
+	if mock.Request.Match(request("q=books&tag=abc&tag=az", http.Header{"Content-Type": {"application/json"}, "X-Debug": {"1"}})) {
+		t.Error("request with an undeclared header should not match")
+	}
+	transportHeaders := http.Header{"Content-Type": {"application/json"}, "User-Agent": {"curl/8.0"}, "Accept-Encoding": {"gzip"}, "Content-Length": {"0"}, "Connection": {"close"}}
+	if !mock.Request.Match(request("q=books&tag=abc&tag=az", transportHeaders)) {
+		t.Error("request with undeclared transport-level headers should match")
+	}
+
+	report := mock.Request.Check(request("q=books&tag=abc&tag=az&debug=1&verbose=1", jsonHeaders))
+	if len(report.Mismatches) != 1 || report.Mismatches[0].Field != "query_params" || report.Mismatches[0].Actual != "debug, verbose" {
+		t.Errorf("report = %+v", report)
+	}
+
+	// In ordered mode, each value is matched by the matcher at the same position.
+	mock.Request.QueryParamsStrict = StrictOrdered
+	if !mock.Request.Match(request("q=books&tag=az&tag=abc", jsonHeaders)) {
+		t.Error("request with values in the declared order should match")
+	}
+	if mock.Request.Match(request("q=books&tag=abc&tag=az", jsonHeaders)) {
+		t.Error("request with values in another order should not match")
+	}
+
+	// Strict mode without declared keys forbids any.
+	mock.Request.QueryParams = nil
+	if mock.Request.Match(request("q=books", jsonHeaders)) || !mock.Request.Match(request("", jsonHeaders)) {
+		t.Error("strict mode without query parameters should only match requests without any")
+	}
+
+	var strictness struct {
+		Default Strictness `json:"default" yaml:"default"`
+		Strict  Strictness `json:"strict" yaml:"strict"`
+		Ordered Strictness `json:"ordered" yaml:"ordered"`
+	}
+	if err := json.Unmarshal([]byte(`{"default": false, "strict": true, "ordered": "ordered"}`), &strictness); err != nil {
+		t.Fatal(err)
+	}
+	if strictness.Default != NotStrict || strictness.Strict != Strict || strictness.Ordered != StrictOrdered {
+		t.Errorf("strictness = %+v", strictness)
+	}
+	if b, _ := yaml.Marshal(strictness); string(b) != "default: false\nstrict: true\nordered: ordered\n" {
+		t.Errorf("yaml = %s", b)
+	}
+	if err := yaml.Unmarshal([]byte("strict: always"), &strictness); err == nil {
+		t.Error("an invalid strict mode should be rejected")
+	}
+}
+
+func TestContainJSONMatcher(t *testing.T) {
+	body := `{"user": {"name": "alice", "age": 30, "roles": ["admin", "dev"]}, "tags": [{"id": 1, "on": true}, {"id": 2}], "debug": false}`
+
+	for _, tc := range []struct {
+		expected string
+		arrays   string
+		message  string
+	}{
+		{expected: `{"user": {"name": "alice"}}`},
+		{expected: `{"user": {"roles": ["admin", "dev"]}, "tags": [{"id": 1}, {"id": 2}]}`},
+		{expected: `{"user": {"roles": ["dev", "admin"]}}`, message: `Expected $.user.roles[0] to be "dev" (but was "admin")!`},
+		{expected: `{"user": {"roles": ["dev", "admin"]}}`, arrays: JSONArraysUnordered},
+		{expected: `{"user": {"roles": ["dev"]}}`, arrays: JSONArraysUnordered, message: "Expected $.user.roles to have 1 items (but it has 2)!"},
+		{expected: `{"user": {"roles": ["dev"]}, "tags": [{"id": 2}]}`, arrays: JSONArraysContains},
+		{expected: `{"tags": [{"id": 3}]}`, arrays: JSONArraysContains, message: `Expected $.tags to contain an item matching {"id":3} (but it didn't)!`},
+		{expected: `{"user": {"email": "alice@example.com"}}`, message: "Expected $.user.email to be present (but it wasn't)!"},
+		{expected: `{"user": {"age": "30"}}`, message: `Expected $.user.age to be "30" (but was 30)!`},
+		{expected: `{"debug": false, "user": "alice"}`, message: `Expected $.user to be "alice" (but was {"age":30,"name":"alice","roles":["admin","dev"]})!`},
+	} {
+		sm := StringMatcher{Matcher: ContainJSONMatcherName, Value: tc.expected, Arrays: tc.arrays}
+		if err := sm.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if message := sm.Explain(body); message != tc.message {
+			t.Errorf("%s (%s): got %q, want %q", tc.expected, tc.arrays, message, tc.message)
+		}
+	}
+
+	// The document may be written as an object, in YAML and in JSON, and matches nested values.
+	var mock Mock
+	err := yaml.Unmarshal([]byte(`
+request:
+  path: /users
+  body:
+    user: {matcher: ShouldContainJSON, value: {roles: [dev]}, arrays: contains}
+response:
+  status: 200
+`), &mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !mock.Request.Match(Request{Method: "POST", Path: "/users", BodyString: body}) {
+		t.Error("a body field containing the document should match")
+	}
+	b, err := json.Marshal(mock.Request.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"user":{"matcher":"ShouldContainJSON","value":"{\"roles\":[\"dev\"]}","arrays":"contains"}}`; string(b) != want {
+		t.Errorf("body matcher = %s, want %s", b, want)
+	}
+
+	var sm StringMatcher
+	if err := json.Unmarshal([]byte(`{"matcher": "ShouldContainJSON", "value": {"debug": false}}`), &sm); err != nil {
+		t.Fatal(err)
+	}
+	if sm.Value != `{"debug":false}` || !sm.Match(body) {
+		t.Errorf("matcher = %s", sm)
+	}
+
+	for _, invalid := range []string{
+		`{"matcher": "ShouldContainJSON", "value": "{"}`,
+		`{"matcher": "ShouldContainJSON", "value": {}, "arrays": "sorted"}`,
+		`{"matcher": "ShouldEqual", "value": "a", "arrays": "contains"}`,
+	} {
+		if err := json.Unmarshal([]byte(invalid), &StringMatcher{}); err == nil {
+			t.Errorf("%s should be rejected", invalid)
+		}
+	}
+}
+
+// TestMatchersCompiledOnValidate checks that the expressions of the matchers are compiled along
+// with the matchers, rather than on each request.
+func TestMatchersCompiledOnValidate(t *testing.T) {
+	var mock Mock
+	err := yaml.Unmarshal([]byte(`
+request:
+  path: {matcher: ShouldMatchPathTemplate, value: "/users/{id}"}
+  headers:
+    X-Trace: {any_of: [{matcher: ShouldMatch, value: "^[a-f0-9]+$"}, {matcher: ShouldNotMatch, value: "^-"}]}
+response:
+  status: 200
+`), &mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	trace := mock.Request.Headers["X-Trace"][0]
+	if mock.Request.Path.re == nil || trace.AnyOf[0].re == nil || trace.AnyOf[1].re == nil || mock.Request.Method.re == nil {
+		t.Error("expected the regular expressions to be compiled")
+	}
+
+	for _, y := range []string{
+		`xpath: {"//id": "1"}`,
+		`json_path: {"$.id": "1"}`,
+	} {
+		var bm BodyMatcher
+		if err := yaml.Unmarshal([]byte(y), &bm); err != nil {
+			t.Fatal(err)
+		}
+		if (bm.bodyXPath != nil && len(bm.bodyXPath.compiled) != 1) || (bm.bodyPath != nil && len(bm.bodyPath.compiled) != 1) {
+			t.Errorf("%s: expected the expression to be compiled", y)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	assertions "github.com/smarty/assertions"
+)
+
+// typedMatchers receive the raw decoded value of a body field (float64, bool, nil, []interface{},
+// map[string]interface{}...) instead of its string form. Numeric and boolean matchers also accept
+// the string form of their operand, so that they apply to headers, query parameters or XPath
+// results as well; type checks are strict.
+var typedMatchers = map[string]bool{
+	"ShouldAlmostEqual":            true,
+	"ShouldNotAlmostEqual":         true,
+	"ShouldBeGreaterThan":          true,
+	"ShouldBeGreaterThanOrEqualTo": true,
+	"ShouldBeLessThan":             true,
+	"ShouldBeLessThanOrEqualTo":    true,
+	"ShouldBeBetween":              true,
+	"ShouldNotBeBetween":           true,
+	"ShouldHaveLength":             true,
+	"ShouldBeTrue":                 true,
+	"ShouldBeFalse":                true,
+	"ShouldBeNull":                 true,
+	"ShouldNotBeNull":              true,
+	"ShouldBeString":               true,
+	"ShouldBeNumber":               true,
+	"ShouldBeBoolean":              true,
+	"ShouldBeArray":                true,
+	"ShouldBeObject":               true,
+}
+
+func ShouldAlmostEqual(value interface{}, expected ...interface{}) string {
+	return compareNumbers("ShouldAlmostEqual", value, expected, assertions.ShouldAlmostEqual)
+}
+
+func ShouldNotAlmostEqual(value interface{}, expected ...interface{}) string {
+	return compareNumbers("ShouldNotAlmostEqual", value, expected, assertions.ShouldNotAlmostEqual)
+}
+
+func ShouldBeGreaterThan(value interface{}, expected ...interface{}) string {
+	return compareNumbers("ShouldBeGreaterThan", value, expected, assertions.ShouldBeGreaterThan)
+}
+
+func ShouldBeGreaterThanOrEqualTo(value interface{}, expected ...interface{}) string {
+	return compareNumbers("ShouldBeGreaterThanOrEqualTo", value, expected, assertions.ShouldBeGreaterThanOrEqualTo)
+}
+
+func ShouldBeLessThan(value interface{}, expected ...interface{}) string {
+	return compareNumbers("ShouldBeLessThan", value, expected, assertions.ShouldBeLessThan)
+}
+
+func ShouldBeLessThanOrEqualTo(value interface{}, expected ...interface{}) string {
+	return compareNumbers("ShouldBeLessThanOrEqualTo", value, expected, assertions.ShouldBeLessThanOrEqualTo)
+}
+
+func ShouldBeBetween(value interface{}, expected ...interface{}) string {
+	return compareRange("ShouldBeBetween", value, expected, assertions.ShouldBeBetweenOrEqual)
+}
+
+func ShouldNotBeBetween(value interface{}, expected ...interface{}) string {
+	return compareRange("ShouldNotBeBetween", value, expected, assertions.ShouldNotBeBetweenOrEqual)
+}
+
+func ShouldHaveLength(value interface{}, expected ...interface{}) string {
+	length, err := parseLength(expectedString(expected))
+	if err != nil {
+		return fmt.Sprintf("ShouldHaveLength %v", err)
+	}
+	return assertions.ShouldHaveLength(value, length)
+}
+
+func ShouldBeTrue(value interface{}, _ ...interface{}) string {
+	b, ok := toBool(value)
+	if !ok {
+		return fmt.Sprintf("Expected %v to be a boolean (but it wasn't)!", value)
+	}
+	return assertions.ShouldBeTrue(b)
+}
+
+func ShouldBeFalse(value interface{}, _ ...interface{}) string {
+	b, ok := toBool(value)
+	if !ok {
+		return fmt.Sprintf("Expected %v to be a boolean (but it wasn't)!", value)
+	}
+	return assertions.ShouldBeFalse(b)
+}
+
+func ShouldBeNull(value interface{}, _ ...interface{}) string {
+	return assertions.ShouldBeNil(value)
+}
+
+func ShouldNotBeNull(value interface{}, _ ...interface{}) string {
+	return assertions.ShouldNotBeNil(value)
+}
+
+func ShouldBeString(value interface{}, _ ...interface{}) string {
+	return expectType(value, "a string", func(v interface{}) bool { _, ok := v.(string); return ok })
+}
+
+func ShouldBeNumber(value interface{}, _ ...interface{}) string {
+	return expectType(value, "a number", func(v interface{}) bool {
+		_, isString := v.(string)
+		_, isNumber := toNumber(v)
+		return isNumber && !isString
+	})
+}
+
+func ShouldBeBoolean(value interface{}, _ ...interface{}) string {
+	return expectType(value, "a boolean", func(v interface{}) bool { _, ok := v.(bool); return ok })
+}
+
+func ShouldBeArray(value interface{}, _ ...interface{}) string {
+	return expectType(value, "an array", func(v interface{}) bool { _, ok := v.([]interface{}); return ok })
+}
+
+func ShouldBeObject(value interface{}, _ ...interface{}) string {
+	return expectType(value, "an object", func(v interface{}) bool { _, ok := v.(map[string]interface{}); return ok })
+}
+
+func compareNumbers(name string, value interface{}, expected []interface{}, assertion Assertion) string {
+	actual, ok := toNumber(value)
+	if !ok {
+		return fmt.Sprintf("%s works only with numbers, got %v", name, value)
+	}
+	operand, err := strconv.ParseFloat(strings.TrimSpace(expectedString(expected)), 64)
+	if err != nil {
+		return fmt.Sprintf("%s expects a number, got %q", name, expectedString(expected))
+	}
+	return assertion(actual, operand)
+}
+
+func compareRange(name string, value interface{}, expected []interface{}, assertion Assertion) string {
+	actual, ok := toNumber(value)
+	if !ok {
+		return fmt.Sprintf("%s works only with numbers, got %v", name, value)
+	}
+	lower, upper, err := parseRange(expectedString(expected))
+	if err != nil {
+		return fmt.Sprintf("%s %v", name, err)
+	}
+	return assertion(actual, lower, upper)
+}
+
+func expectType(value interface{}, kind string, check func(interface{}) bool) string {
+	if !check(value) {
+		return fmt.Sprintf("Expected %v to be %s (but it wasn't)!", value, kind)
+	}
+	return ""
+}
+
+func expectedString(expected []interface{}) string {
+	if len(expected) == 0 {
+		return ""
+	}
+	s, _ := expected[0].(string)
+	return s
+}
+
+// parseRange reads the inclusive bounds of ShouldBeBetween, written "lower,upper".
+func parseRange(value string) (float64, float64, error) {
+	bounds := strings.Split(value, ",")
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("expects two numbers written \"lower,upper\", got %q", value)
+	}
+	lower, errLower := strconv.ParseFloat(strings.TrimSpace(bounds[0]), 64)
+	upper, errUpper := strconv.ParseFloat(strings.TrimSpace(bounds[1]), 64)
+	if errLower != nil || errUpper != nil {
+		return 0, 0, fmt.Errorf("expects two numbers written \"lower,upper\", got %q", value)
+	}
+	if lower > upper {
+		return 0, 0, fmt.Errorf("expects a lower bound lesser than the upper bound, got %q", value)
+	}
+	return lower, upper, nil
+}
+
+func parseLength(value string) (int, error) {
+	length, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || length < 0 {
+		return 0, fmt.Errorf("expects a positive integer, got %q", value)
+	}
+	return length, nil
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toBool(value interface{}) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		return b, err == nil
+	default:
+		return false, false
+	}
+}
+
+// validateTypedMatcher checks the operand of the typed matchers that expect one. ShouldAlmostEqual
+// and ShouldNotAlmostEqual accepted any value before, so it is left unchecked for them to keep
+// loading existing mocks.
+func validateTypedMatcher(sm StringMatcher) error {
+	var err error
+	switch sm.Matcher {
+	case "ShouldBeGreaterThan", "ShouldBeGreaterThanOrEqualTo", "ShouldBeLessThan", "ShouldBeLessThanOrEqualTo":
+		if _, parseErr := strconv.ParseFloat(strings.TrimSpace(sm.Value), 64); parseErr != nil {
+			err = fmt.Errorf("expects a number, got %q", sm.Value)
+		}
+	case "ShouldBeBetween", "ShouldNotBeBetween":
+		_, _, err = parseRange(sm.Value)
+	case "ShouldHaveLength":
+		_, err = parseLength(sm.Value)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value provided to %q operator: %v", sm.Matcher, err)
+	}
+	return nil
+}
+
+// stringifyValue returns the string form of a decoded JSON value: scalars as their plain text,
+// objects and arrays serialized as JSON so that they can be used with ShouldEqualJSON.
+func stringifyValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,109 @@
+package types
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// XPathMatcher matches an XML (or SOAP) body. Each key of XPath is an XPath expression evaluated
+// against the parsed document; the matcher associated to it must be satisfied by at least one of
+// the selected nodes (or by the value of the expression when it is not a node-set, e.g. count()).
+// Namespaces binds the prefixes used in the expressions to namespace URIs, as the prefixes
+// declared in the request document itself are not known in advance.
+type XPathMatcher struct {
+	XPath      map[string]StringMatcher `json:"xpath" yaml:"xpath"`
+	Namespaces map[string]string        `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+
+	// compiled holds the expressions compiled by Validate.
+	compiled map[string]*xpath.Expr
+}
+
+// Validate compiles the expressions, once for all the requests to match.
+func (xm *XPathMatcher) Validate() error {
+	compiled := make(map[string]*xpath.Expr, len(xm.XPath))
+	for expr := range xm.XPath {
+		c, err := xpath.CompileWithNS(expr, xm.Namespaces)
+		if err != nil {
+			return fmt.Errorf("invalid XPath expression %q: %v", expr, err)
+		}
+		compiled[expr] = c
+	}
+	xm.compiled = compiled
+	return nil
+}
+
+func (xm XPathMatcher) Match(value string) bool {
+	doc, err := xmlquery.Parse(strings.NewReader(value))
+	if err != nil {
+		slog.Debug("Failed to parse request body as XML", "error", err)
+		return false
+	}
+
+	for expr, matcher := range xm.XPath {
+		compiled := xm.compiled[expr]
+		if compiled == nil {
+			// The matcher was built in code, without Validate.
+			var err error
+			if compiled, err = xpath.CompileWithNS(expr, xm.Namespaces); err != nil {
+				slog.Error("Invalid XPath expression", "xpath", expr, "error", err)
+				return false
+			}
+		}
+
+		matched := false
+		for _, v := range evaluateXPath(doc, compiled) {
+			if matcher.Match(v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluateXPath returns the string values selected by expr: one per node for a node-set, or the
+// single result of a scalar expression. An empty node-set yields no value at all.
+func evaluateXPath(doc *xmlquery.Node, expr *xpath.Expr) []string {
+	switch res := expr.Evaluate(xmlquery.CreateXPathNavigator(doc)).(type) {
+	case *xpath.NodeIterator:
+		values := []string{}
+		for res.MoveNext() {
+			values = append(values, res.Current().Value())
+		}
+		return values
+	case float64:
+		return []string{strconv.FormatFloat(res, 'f', -1, 64)}
+	case bool:
+		return []string{strconv.FormatBool(res)}
+	case string:
+		return []string{res}
+	default:
+		return []string{fmt.Sprint(res)}
+	}
+}
+
+// isXPathBody tells an XPath body matcher ({xpath: {...}, namespaces: {...}}) apart from a map of
+// JSON paths, which may legitimately contain a "xpath" key holding a single matcher.
+func isXPathBody(raw map[string]interface{}) bool {
+	exprs, ok := raw["xpath"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if _, isMatcher := exprs["matcher"]; isMatcher {
+		return false
+	}
+	for key := range raw {
+		if key != "xpath" && key != "namespaces" {
+			return false
+		}
+	}
+	return true
+}
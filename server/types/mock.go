@@ -1,12 +1,15 @@
 package types
 
 import (
+	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
 	"strings"
@@ -30,15 +33,26 @@ func (m Mocks) Clone() Mocks {
 type Mock struct {
 	Request         MockRequest          `json:"request,omitempty" yaml:"request"`
 	Response        *MockResponse        `json:"response,omitempty" yaml:"response,omitempty"`
+	Responses       *MockResponses       `json:"responses,omitempty" yaml:"responses,omitempty"`
 	Context         *MockContext         `json:"context,omitempty" yaml:"context,omitempty"`
 	State           *MockState           `json:"state,omitempty" yaml:"state,omitempty"`
 	DynamicResponse *DynamicMockResponse `json:"dynamic_response,omitempty" yaml:"dynamic_response,omitempty"`
 	Proxy           *MockProxy           `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	WebSocket       *MockWebSocket       `json:"websocket,omitempty" yaml:"websocket,omitempty"`
+	Fault           MockFaults           `json:"fault,omitempty" yaml:"fault,omitempty"`
 }
 
 func (m *Mock) Validate() error {
-	if m.Response == nil && m.DynamicResponse == nil && m.Proxy == nil {
-		return errors.New("The route must define at least a response, a dynamic response or a proxy")
+	if m.Response == nil && m.Responses == nil && m.DynamicResponse == nil && m.Proxy == nil && m.WebSocket == nil {
+		return errors.New("The route must define at least a response, a list of responses, a dynamic response, a proxy or a websocket")
+	}
+
+	if m.WebSocket != nil && (m.Response != nil || m.Responses != nil || m.DynamicResponse != nil || m.Proxy != nil) {
+		return errors.New("The route must define either a websocket or a response, not both of them")
+	}
+
+	if m.Responses != nil && (m.Response != nil || m.DynamicResponse != nil || m.Proxy != nil) {
+		return errors.New("The route must define either a list of responses or a response, a dynamic response or a proxy, not several of them")
 	}
 
 	if m.Response != nil && m.DynamicResponse != nil && m.Proxy != nil {
@@ -46,15 +60,59 @@ func (m *Mock) Validate() error {
 	}
 
 	m.Request.Path.Value = strings.TrimSpace(m.Request.Path.Value)
-	if m.Request.Path.Value == "" {
+	if m.Request.Path.Value == "" && !m.Request.Path.IsComposite() {
 		m.Request.Path.Matcher = "ShouldMatch"
 		m.Request.Path.Value = ".*"
+		if err := m.Request.Path.Validate(); err != nil {
+			return err
+		}
 	}
 
 	m.Request.Method.Value = strings.TrimSpace(m.Request.Method.Value)
-	if m.Request.Method.Value == "" {
+	if m.Request.Method.Value == "" && !m.Request.Method.IsComposite() {
 		m.Request.Method.Matcher = "ShouldMatch"
 		m.Request.Method.Value = ".*"
+		if err := m.Request.Method.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if m.Request.Body != nil {
+		if err := m.Request.Body.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if m.Request.JWT != nil {
+		if err := m.Request.JWT.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if m.Response != nil {
+		if err := m.Response.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if m.Responses != nil {
+		if err := m.Responses.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if m.Proxy != nil && m.Proxy.Bandwidth < 0 {
+		return fmt.Errorf("invalid proxy bandwidth: %d", m.Proxy.Bandwidth)
+	}
+
+	if m.WebSocket != nil {
+		if err := m.WebSocket.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := m.Fault.Validate(); err != nil {
+		return err
 	}
 
 	if m.DynamicResponse != nil && !m.DynamicResponse.Engine.IsValid() {
@@ -98,6 +156,9 @@ func (m *Mock) CloneAndReset() *Mock {
 		DynamicResponse: m.DynamicResponse,
 		Proxy:           m.Proxy,
 		Response:        m.Response,
+		Responses:       m.Responses,
+		WebSocket:       m.WebSocket,
+		Fault:           m.Fault,
 	}
 }
 
@@ -107,42 +168,203 @@ type MockRequest struct {
 	Body        *BodyMatcher    `json:"body,omitempty" yaml:"body,omitempty"`
 	QueryParams MultiMapMatcher `json:"query_params,omitempty" yaml:"query_params,omitempty"`
 	Headers     MultiMapMatcher `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Cookies     MultiMapMatcher `json:"cookies,omitempty" yaml:"cookies,omitempty"`
+	Origin      *StringMatcher  `json:"origin,omitempty" yaml:"origin,omitempty"`
+	Host        *StringMatcher  `json:"host,omitempty" yaml:"host,omitempty"`
+	Scheme      *StringMatcher  `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	TLS         *TLSMatcher     `json:"tls,omitempty" yaml:"tls,omitempty"`
+	JWT         *JWTMatcher     `json:"jwt,omitempty" yaml:"jwt,omitempty"`
+	GraphQL     *GraphQLMatcher `json:"graphql,omitempty" yaml:"graphql,omitempty"`
+
+	QueryParamsStrict Strictness `json:"query_params_strict,omitempty" yaml:"query_params_strict,omitempty"`
+	HeadersStrict     Strictness `json:"headers_strict,omitempty" yaml:"headers_strict,omitempty"`
+	CookiesStrict     Strictness `json:"cookies_strict,omitempty" yaml:"cookies_strict,omitempty"`
 }
 
 func (mr MockRequest) Match(req Request) bool {
-	matchMethod := mr.Method.Match(req.Method)
-	if !matchMethod {
-		slog.Debug("Method did not match")
-		return false
-	}
-	matchPath := mr.Path.Match(req.Path)
-	if !matchPath {
-		slog.Debug("Path did not match")
-		return false
-	}
-	matchHeaders := mr.Headers == nil || mr.Headers.MatchHeaders(req.Headers)
-	if !matchHeaders {
-		slog.Debug("Headers did not match")
-		return false
-	}
-	matchQueryParams := mr.QueryParams == nil || mr.QueryParams.Match(req.QueryParams)
-	if !matchQueryParams {
-		slog.Debug("Query params did not match")
-		return false
-	}
-	matchBody := mr.Body == nil || mr.Body.Match(req.Headers, req.BodyString)
-	if !matchBody {
-		slog.Debug("Body did not match")
-		return false
-	}
-	return true
+	r := matchReporter{fast: true}
+	mr.check(&r, req)
+	return !r.failed()
+}
+
+// Check evaluates every criterion of the mock against req, where Match stops at the first one
+// that isn't satisfied, and reports why req doesn't match.
+func (mr MockRequest) Check(req Request) MatchReport {
+	r := matchReporter{}
+	mr.check(&r, req)
+	return r.report
 }
 
+func (mr MockRequest) check(r *matchReporter, req Request) {
+	r.string("method", mr.Method, req.Method)
+	r.string("path", mr.Path, req.Path)
+	if mr.Origin != nil {
+		r.string("origin", *mr.Origin, req.Origin)
+	}
+	if mr.Host != nil {
+		r.string("host", *mr.Host, req.Host)
+	}
+	if mr.Scheme != nil {
+		r.string("scheme", *mr.Scheme, req.Scheme)
+	}
+	if mr.TLS != nil {
+		r.tls(*mr.TLS, req.TLS)
+	}
+	if mr.JWT != nil {
+		r.jwt(*mr.JWT, req.Headers)
+	}
+	if mr.GraphQL != nil {
+		r.graphql(*mr.GraphQL, req)
+	}
+	if mr.Headers != nil || mr.HeadersStrict != NotStrict {
+		r.multimap("headers", mr.Headers, req.Headers.Values, mr.HeadersStrict)
+		if mr.HeadersStrict != NotStrict {
+			r.undeclared("headers", mr.Headers, req.Headers, true)
+		}
+	}
+	if (mr.Cookies != nil || mr.CookiesStrict != NotStrict) && !r.done() {
+		cookies := req.Cookies()
+		r.multimap("cookies", mr.Cookies, func(key string) []string { return cookies[key] }, mr.CookiesStrict)
+		if mr.CookiesStrict != NotStrict {
+			r.undeclared("cookies", mr.Cookies, cookies, false)
+		}
+	}
+	if mr.QueryParams != nil || mr.QueryParamsStrict != NotStrict {
+		r.multimap("query_params", mr.QueryParams, func(key string) []string { return req.QueryParams[key] }, mr.QueryParamsStrict)
+		if mr.QueryParamsStrict != NotStrict {
+			r.undeclared("query_params", mr.QueryParams, req.QueryParams, false)
+		}
+	}
+	if mr.Body != nil {
+		// Structured matchers, such as the fields of a multipart form with a binary file, parse
+		// the body itself rather than its base64 form.
+		body := req.BodyString
+		if req.BodyBase64 && mr.Body.bodyString == nil {
+			body = string(req.RawBody())
+		}
+		r.body(*mr.Body, req.Headers, body, req.Multipart)
+	}
+}
+
+// MockResponse is a static response. Binary bodies are declared base64 encoded in BodyBase64
+// instead of Body. A body sent in several chunks is declared in Chunks instead, and a stream of
+// server-sent events in SSE.
+//
+// Delay is the time to first byte of the response. Bandwidth limits the speed, in bytes per
+// second, at which the body is sent, and TotalDelay, counted from the reception of the request,
+// spreads the body so that the response ends after it.
+//
+// Compress compresses the body, with a fixed coding or one negotiated from the Accept-Encoding
+// header of the request, and sets the Content-Encoding and Vary headers.
 type MockResponse struct {
-	Body    string         `json:"body,omitempty" yaml:"body,omitempty"`
-	Status  int            `json:"status" yaml:"status"`
-	Delay   Delay          `json:"delay,omitempty" yaml:"delay,omitempty"`
-	Headers MapStringSlice `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body       string          `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyBase64 string          `json:"body_base64,omitempty" yaml:"body_base64,omitempty"`
+	Chunks     []ResponseChunk `json:"chunks,omitempty" yaml:"chunks,omitempty"`
+	SSE        *SSEResponse    `json:"sse,omitempty" yaml:"sse,omitempty"`
+	Status     int             `json:"status" yaml:"status"`
+	Delay      Delay           `json:"delay,omitempty" yaml:"delay,omitempty"`
+	TotalDelay *Delay          `json:"total_delay,omitempty" yaml:"total_delay,omitempty"`
+	Bandwidth  int             `json:"bandwidth,omitempty" yaml:"bandwidth,omitempty"`
+	Compress   Compression     `json:"compress,omitempty" yaml:"compress,omitempty"`
+	Headers    MapStringSlice  `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Cookies    MockCookies     `json:"cookies,omitempty" yaml:"cookies,omitempty"`
+}
+
+// ResponseChunk is a part of a streamed response body, sent and flushed after its own delay,
+// counted from the previous chunk.
+type ResponseChunk struct {
+	Body       string `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyBase64 string `json:"body_base64,omitempty" yaml:"body_base64,omitempty"`
+	Delay      Delay  `json:"delay,omitempty" yaml:"delay,omitempty"`
+}
+
+func (mr MockResponse) Validate() error {
+	if mr.Body != "" && mr.BodyBase64 != "" {
+		return errors.New("The response must define either a body or a body_base64, not both of them")
+	}
+	if _, err := mr.BodyBytes(); err != nil {
+		return err
+	}
+	if mr.Bandwidth < 0 {
+		return fmt.Errorf("invalid response bandwidth: %d", mr.Bandwidth)
+	}
+	if err := mr.Delay.validate(); err != nil {
+		return err
+	}
+	if mr.TotalDelay != nil {
+		if err := mr.TotalDelay.validate(); err != nil {
+			return fmt.Errorf("invalid total_delay: %w", err)
+		}
+	}
+	if mr.Compress != "" {
+		if !mr.Compress.IsValid() {
+			return fmt.Errorf("The response compression must be one of the following: %v", append([]Compression{CompressAuto}, Compressions...))
+		}
+		if len(mr.Chunks) > 0 || mr.SSE != nil {
+			return errors.New("The response compression only applies to a body, not to chunks nor sse events")
+		}
+	}
+	if (mr.Bandwidth > 0 || mr.TotalDelay != nil) && (len(mr.Chunks) > 0 || mr.SSE != nil) {
+		return errors.New("The response bandwidth and total_delay only apply to a body, not to chunks nor sse events")
+	}
+	if len(mr.Chunks) > 0 && (mr.Body != "" || mr.BodyBase64 != "") {
+		return errors.New("The response must define either a body or chunks, not both of them")
+	}
+	if mr.SSE != nil {
+		if mr.Body != "" || mr.BodyBase64 != "" || len(mr.Chunks) > 0 {
+			return errors.New("The response must define either a body, chunks or sse events, not several of them")
+		}
+		if err := mr.SSE.Validate(); err != nil {
+			return err
+		}
+	}
+	for i, chunk := range mr.Chunks {
+		if chunk.Body != "" && chunk.BodyBase64 != "" {
+			return fmt.Errorf("The chunk %d must define either a body or a body_base64, not both of them", i)
+		}
+		if _, err := chunk.BodyBytes(); err != nil {
+			return fmt.Errorf("invalid chunk %d: %w", i, err)
+		}
+		if err := chunk.Delay.validate(); err != nil {
+			return fmt.Errorf("invalid chunk %d: %w", i, err)
+		}
+	}
+	return mr.Cookies.Validate()
+}
+
+// Compressed returns a copy of the response whose body is compressed with coding.
+func (mr MockResponse) Compressed(coding string) (*MockResponse, error) {
+	body, err := mr.BodyBytes()
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := EncodeContent(body, coding)
+	if err != nil {
+		return nil, err
+	}
+	mr.Body, mr.BodyBase64 = "", base64.StdEncoding.EncodeToString(encoded)
+	return &mr, nil
+}
+
+// BodyBytes returns the body to send, decoding BodyBase64 when it is set.
+func (mr MockResponse) BodyBytes() ([]byte, error) {
+	return decodeBody(mr.Body, mr.BodyBase64)
+}
+
+// BodyBytes returns the body of the chunk, decoding BodyBase64 when it is set.
+func (rc ResponseChunk) BodyBytes() ([]byte, error) {
+	return decodeBody(rc.Body, rc.BodyBase64)
+}
+
+func decodeBody(body, bodyBase64 string) ([]byte, error) {
+	if bodyBase64 == "" {
+		return []byte(body), nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(bodyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response body_base64: %w", err)
+	}
+	return decoded, nil
 }
 
 type DynamicMockResponse struct {
@@ -153,15 +375,37 @@ type DynamicMockResponse struct {
 type MockProxy struct {
 	Host           string         `json:"host" yaml:"host"`
 	Delay          Delay          `json:"delay,omitempty" yaml:"delay,omitempty"`
+	TotalDelay     *Delay         `json:"total_delay,omitempty" yaml:"total_delay,omitempty"`
+	Bandwidth      int            `json:"bandwidth,omitempty" yaml:"bandwidth,omitempty"`
 	FollowRedirect bool           `json:"follow_redirect,omitempty" yaml:"follow_redirect,omitempty"`
 	SkipVerifyTLS  bool           `json:"skip_verify_tls,omitempty" yaml:"skip_verify_tls,omitempty"`
 	KeepHost       bool           `json:"keep_host,omitempty" yaml:"keep_host,omitempty"`
 	Headers        MapStringSlice `json:"headers,omitempty" yaml:"headers,omitempty"`
 }
 
+// Delay is a fixed delay, a delay picked uniformly between Min and Max, or a delay following a
+// normal or log-normal Distribution, described by its Mean and StdDev or by its P50 and P99
+// percentiles, and bounded by Min and, when set, Max.
 type Delay struct {
-	Min time.Duration `json:"min,omitempty" yaml:"min,omitempty"`
-	Max time.Duration `json:"max,omitempty" yaml:"max,omitempty"`
+	Min          time.Duration     `json:"min,omitempty" yaml:"min,omitempty"`
+	Max          time.Duration     `json:"max,omitempty" yaml:"max,omitempty"`
+	Distribution DelayDistribution `json:"distribution,omitempty" yaml:"distribution,omitempty"`
+	Mean         time.Duration     `json:"mean,omitempty" yaml:"mean,omitempty"`
+	StdDev       time.Duration     `json:"stddev,omitempty" yaml:"stddev,omitempty"`
+	P50          time.Duration     `json:"p50,omitempty" yaml:"p50,omitempty"`
+	P99          time.Duration     `json:"p99,omitempty" yaml:"p99,omitempty"`
+}
+
+// Duration returns a duration picked from the distribution of the delay, uniformly between its
+// bounds by default.
+func (d Delay) Duration() time.Duration {
+	if d.Distribution == NormalDelay || d.Distribution == LogNormalDelay {
+		return d.sample()
+	}
+	if d.Min == d.Max {
+		return d.Min
+	}
+	return time.Duration(rand.Int64N(int64(d.Max-d.Min)) + int64(d.Min))
 }
 
 func (d *Delay) UnmarshalJSON(data []byte) error {
@@ -173,28 +417,40 @@ func (d *Delay) UnmarshalJSON(data []byte) error {
 		return d.validate()
 	}
 
-	// Object form: {"min": ..., "max": ...}. Each bound is a duration string ("10ms") or a number
-	// of nanoseconds — the same shapes the Lua and go_template_yaml engines already accept.
+	// Object form: {"min": ..., "max": ...}, with an optional distribution. Each duration is a
+	// string ("10ms") or a number of nanoseconds — the same shapes the Lua and go_template_yaml
+	// engines already accept.
 	var res struct {
-		Min json.RawMessage `json:"min"`
-		Max json.RawMessage `json:"max"`
+		Min          json.RawMessage   `json:"min"`
+		Max          json.RawMessage   `json:"max"`
+		Distribution DelayDistribution `json:"distribution"`
+		Mean         json.RawMessage   `json:"mean"`
+		StdDev       json.RawMessage   `json:"stddev"`
+		P50          json.RawMessage   `json:"p50"`
+		P99          json.RawMessage   `json:"p99"`
 	}
 	if err := json.Unmarshal(data, &res); err != nil {
 		return err
 	}
-	if len(res.Min) > 0 {
-		v, _, err := parseJSONDuration(res.Min)
-		if err != nil {
-			return err
-		}
-		d.Min = v
-	}
-	if len(res.Max) > 0 {
-		v, _, err := parseJSONDuration(res.Max)
-		if err != nil {
-			return err
+	d.Distribution = res.Distribution
+	for _, field := range []struct {
+		raw json.RawMessage
+		dst *time.Duration
+	}{
+		{res.Min, &d.Min},
+		{res.Max, &d.Max},
+		{res.Mean, &d.Mean},
+		{res.StdDev, &d.StdDev},
+		{res.P50, &d.P50},
+		{res.P99, &d.P99},
+	} {
+		if len(field.raw) > 0 {
+			v, _, err := parseJSONDuration(field.raw)
+			if err != nil {
+				return err
+			}
+			*field.dst = v
 		}
-		d.Max = v
 	}
 	return d.validate()
 }
@@ -227,20 +483,34 @@ func (d *Delay) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	}
 
 	var res struct {
-		Min time.Duration `yaml:"min,flow"`
-		Max time.Duration `yaml:"max,flow"`
+		Min          time.Duration     `yaml:"min,flow"`
+		Max          time.Duration     `yaml:"max,flow"`
+		Distribution DelayDistribution `yaml:"distribution"`
+		Mean         time.Duration     `yaml:"mean"`
+		StdDev       time.Duration     `yaml:"stddev"`
+		P50          time.Duration     `yaml:"p50"`
+		P99          time.Duration     `yaml:"p99"`
 	}
 
 	if err := unmarshal(&res); err != nil {
 		return err
 	}
 
-	d.Min = res.Min
-	d.Max = res.Max
+	*d = Delay(res)
 	return d.validate()
 }
 
 func (d *Delay) validate() error {
+	switch d.Distribution {
+	case "", UniformDelay:
+	case NormalDelay, LogNormalDelay:
+		return d.validateDistribution()
+	default:
+		return fmt.Errorf("The delay distribution must be one of the following: %v", []DelayDistribution{UniformDelay, NormalDelay, LogNormalDelay})
+	}
+	if d.Mean != 0 || d.StdDev != 0 || d.P50 != 0 || d.P99 != 0 {
+		return fmt.Errorf("a %s delay is only defined by its min and max", UniformDelay)
+	}
 	if d.Min < 0 || d.Max < d.Min {
 		return fmt.Errorf("invalid delay range: min => %v, max => %v", d.Min, d.Max)
 	}
@@ -252,11 +522,15 @@ func noFollow(req *http.Request, via []*http.Request) error {
 }
 
 func (mp MockProxy) Redirect(req Request) (*MockResponse, error) {
-	proxyReq, err := http.NewRequest(req.Method, mp.Host+req.Path, strings.NewReader(req.BodyString))
+	proxyReq, err := http.NewRequest(req.Method, mp.Host+req.Path, bytes.NewReader(req.RawBody()))
 	if err != nil {
 		return nil, err
 	}
 	proxyReq.Header = req.Headers.Clone()
+	if req.ContentEncoding != "" {
+		// The body is forwarded decoded.
+		proxyReq.Header.Del("Content-Encoding")
+	}
 	if mp.KeepHost {
 		proxyReq.Host = req.Headers.Get("Host")
 	}
@@ -295,12 +569,19 @@ func (mp MockProxy) Redirect(req Request) (*MockResponse, error) {
 	for key, values := range resp.Header {
 		respHeader[key] = values
 	}
-	return &MockResponse{
-		Status:  resp.StatusCode,
-		Body:    string(body),
-		Headers: respHeader,
-		Delay:   mp.Delay,
-	}, nil
+	response := &MockResponse{
+		Status:     resp.StatusCode,
+		Headers:    respHeader,
+		Delay:      mp.Delay,
+		TotalDelay: mp.TotalDelay,
+		Bandwidth:  mp.Bandwidth,
+	}
+	if bodyString, binary := BinarySafeBody(body); binary {
+		response.BodyBase64 = bodyString
+	} else {
+		response.Body = bodyString
+	}
+	return response, nil
 }
 
 type MockContext struct {
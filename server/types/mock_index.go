@@ -0,0 +1,136 @@
+package types
+
+import (
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+// MockIndex narrows the mocks which may match a request, so that a request isn't matched against
+// every mock of a session. Mocks are indexed by the method they expect when it is a literal, and by
+// the literal path, or literal path prefix, they expect. The candidates of a request keep the order
+// of the indexed mocks, where the last declared comes first.
+type MockIndex struct {
+	buckets map[mockIndexKey][]indexedMock
+}
+
+// mockIndexKey locates the bucket of a mock: an empty method or segment holds the mocks expecting
+// any method or any first path segment.
+type mockIndexKey struct {
+	method  string
+	segment string
+}
+
+type indexedMock struct {
+	position int
+	prefix   string
+	exact    bool
+	mock     *Mock
+}
+
+func (im indexedMock) accepts(path string) bool {
+	if im.exact {
+		return path == im.prefix
+	}
+	return strings.HasPrefix(path, im.prefix)
+}
+
+func NewMockIndex(mocks Mocks) *MockIndex {
+	index := &MockIndex{buckets: map[mockIndexKey][]indexedMock{}}
+	for position, mock := range mocks {
+		prefix, exact := mock.Request.Path.literalPrefix()
+		key := mockIndexKey{
+			method:  mock.Request.Method.literalValue(),
+			segment: prefixSegment(prefix, exact),
+		}
+		index.buckets[key] = append(index.buckets[key], indexedMock{position: position, prefix: prefix, exact: exact, mock: mock})
+	}
+	return index
+}
+
+// Candidates returns the mocks which may match req, in the order of the indexed mocks. Mocks which
+// are not returned can't match req.
+func (idx *MockIndex) Candidates(req Request) Mocks {
+	segment := pathSegment(req.Path)
+	keys := []mockIndexKey{{req.Method, segment}, {req.Method, ""}, {"", segment}, {"", ""}}
+
+	candidates := []indexedMock{}
+	seen := map[mockIndexKey]bool{}
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		for _, indexed := range idx.buckets[key] {
+			if indexed.accepts(req.Path) {
+				candidates = append(candidates, indexed)
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].position < candidates[j].position
+	})
+
+	mocks := make(Mocks, 0, len(candidates))
+	for _, candidate := range candidates {
+		mocks = append(mocks, candidate.mock)
+	}
+	return mocks
+}
+
+// literalValue returns the only value sm matches, or an empty string when it matches others.
+func (sm StringMatcher) literalValue() string {
+	if !sm.IsComposite() && sm.Matcher == "ShouldEqual" {
+		return sm.Value
+	}
+	return ""
+}
+
+// literalPrefix returns a prefix of every value sm matches, and whether it is the only value.
+func (sm StringMatcher) literalPrefix() (string, bool) {
+	if sm.IsComposite() {
+		return "", false
+	}
+	switch sm.Matcher {
+	case "ShouldEqual":
+		return sm.Value, true
+	case "ShouldStartWith":
+		return sm.Value, false
+	case PathTemplateMatcherName:
+		prefix, _, _ := strings.Cut(sm.Value, "{")
+		return prefix, false
+	case "ShouldMatch":
+		return regexpLiteralPrefix(sm.Value), false
+	}
+	return "", false
+}
+
+// regexpLiteralPrefix returns the literal text a regular expression anchored with ^ starts with.
+func regexpLiteralPrefix(pattern string) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil || re.Op != syntax.OpConcat || len(re.Sub) < 2 || re.Sub[0].Op != syntax.OpBeginText {
+		return ""
+	}
+	if literal := re.Sub[1]; literal.Op == syntax.OpLiteral && literal.Flags&syntax.FoldCase == 0 {
+		return string(literal.Rune)
+	}
+	return ""
+}
+
+// prefixSegment returns the first segment of the paths starting with prefix, or an empty string
+// when the prefix doesn't tell it.
+func prefixSegment(prefix string, exact bool) string {
+	if !strings.HasPrefix(prefix, "/") {
+		return ""
+	}
+	segment, _, found := strings.Cut(prefix[1:], "/")
+	if !found && !exact {
+		return ""
+	}
+	return segment
+}
+
+func pathSegment(path string) string {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return segment
+}
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMockIndex(t *testing.T) {
+	var mocks Mocks
+	err := yaml.Unmarshal([]byte(`
+- request: {method: GET, path: /users}
+  response: {status: 200}
+- request: {method: GET, path: {matcher: ShouldMatchPathTemplate, value: "/users/{id}"}}
+  response: {status: 200}
+- request: {path: {matcher: ShouldMatch, value: "^/users/[0-9]+/orders$"}}
+  response: {status: 200}
+- request: {method: {matcher: ShouldMatch, value: "^(GET|HEAD)$"}, path: {matcher: ShouldStartWith, value: /use}}
+  response: {status: 200}
+- request: {method: POST, path: {matcher: ShouldMatch, value: "(?i)^/USERS"}}
+  response: {status: 200}
+- request: {method: DELETE, path: {any_of: [/users, /orders]}}
+  response: {status: 200}
+- request: {method: GET, path: /}
+  response: {status: 200}
+- request: {method: GET, path: /orders/42}
+  response: {status: 200}
+`), &mocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mock := range mocks {
+		if err := mock.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		matcher StringMatcher
+		prefix  string
+		exact   bool
+	}{
+		{StringMatcher{Matcher: "ShouldEqual", Value: "/users"}, "/users", true},
+		{StringMatcher{Matcher: PathTemplateMatcherName, Value: "/users/{id}"}, "/users/", false},
+		{StringMatcher{Matcher: "ShouldMatch", Value: "^/users/[0-9]+/orders$"}, "/users/", false},
+		{StringMatcher{Matcher: "ShouldMatch", Value: "/users"}, "", false},
+		{StringMatcher{Matcher: "ShouldMatch", Value: "(?i)^/USERS"}, "", false},
+		{StringMatcher{AnyOf: []StringMatcher{{Matcher: "ShouldEqual", Value: "/users"}}}, "", false},
+	} {
+		if prefix, exact := tc.matcher.literalPrefix(); prefix != tc.prefix || exact != tc.exact {
+			t.Errorf("literal prefix of %s = %q, %v, want %q, %v", tc.matcher, prefix, exact, tc.prefix, tc.exact)
+		}
+	}
+
+	// The index never leaves out a mock matching the request, and keeps the order of the mocks.
+	index := NewMockIndex(mocks)
+	for _, method := range []string{"GET", "POST", "DELETE", "HEAD", "PUT"} {
+		for _, path := range []string{"/", "/users", "/USERS", "/users/42", "/users/42/orders", "/orders", "/orders/42", "/user", "/other/users"} {
+			req := Request{Method: method, Path: path}
+			candidates := index.Candidates(req)
+			expected := Mocks{}
+			for _, mock := range mocks {
+				if mock.Request.Match(req) {
+					expected = append(expected, mock)
+				}
+			}
+			matching := Mocks{}
+			for _, mock := range candidates {
+				if mock.Request.Match(req) {
+					matching = append(matching, mock)
+				}
+			}
+			if fmt.Sprint(matching) != fmt.Sprint(expected) {
+				t.Errorf("%s %s: matching candidates = %v, want %v", method, path, matching, expected)
+			}
+			if len(candidates) == len(mocks) {
+				t.Errorf("%s %s: the index didn't narrow the mocks", method, path)
+			}
+		}
+	}
+	if candidates := index.Candidates(Request{Method: "GET", Path: "/users/42"}); len(candidates) != 3 {
+		t.Errorf("candidates = %d, want 3", len(candidates))
+	}
+}
@@ -0,0 +1,129 @@
+package types
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FirstMatch returns the mock answering req: the first one of mocks, where the last declared comes
+// first, matching req whose times are not exceeded. It also returns the matching mocks skipped
+// because their times are exceeded. The state of the mocks is left untouched.
+func (m Mocks) FirstMatch(req Request) (*Mock, Mocks) {
+	exceeded := Mocks{}
+	for _, mock := range m {
+		if !mock.Request.Match(req) {
+			debugMock("Skipping mock", mock)
+			continue
+		}
+		if mock.Context.Times > 0 && mock.State.TimesCount >= mock.Context.Times {
+			debugMock("Times exceeded, skipping mock", mock)
+			exceeded = append(exceeded, mock)
+			continue
+		}
+		debugMock("Matching mock", mock)
+		return mock, exceeded
+	}
+	return nil, exceeded
+}
+
+// debugMock logs a mock, only serializing it when debug logs are enabled as large sessions would
+// otherwise spend most of the matching time doing so.
+func debugMock(message string, mock *Mock) {
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	b, _ := yaml.Marshal(mock)
+	slog.Debug(fmt.Sprintf("%s:\n---\n%s\n", message, string(b)))
+}
+
+// RequestDescription describes a request to match against the mocks without sending it. A body
+// which is not a string is sent as JSON.
+type RequestDescription struct {
+	Method      string      `json:"method" yaml:"method"`
+	Path        string      `json:"path" yaml:"path"`
+	Origin      string      `json:"origin,omitempty" yaml:"origin,omitempty"`
+	QueryParams url.Values  `json:"query_params,omitempty" yaml:"query_params,omitempty"`
+	Headers     http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body        interface{} `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// Request builds the request the mock server would receive, so that it is matched exactly like a
+// request actually sent.
+func (rd RequestDescription) Request() (Request, error) {
+	method := strings.ToUpper(rd.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if !strings.HasPrefix(rd.Path, "/") {
+		return Request{}, fmt.Errorf("invalid path %q, it should start with '/'", rd.Path)
+	}
+
+	var body []byte
+	switch value := rd.Body.(type) {
+	case nil:
+	case string:
+		body = []byte(value)
+	default:
+		var err error
+		if body, err = json.Marshal(value); err != nil {
+			return Request{}, fmt.Errorf("invalid body: %w", err)
+		}
+	}
+
+	req, err := http.NewRequest(method, "http://localhost"+rd.Path, io.NopCloser(bytes.NewReader(body)))
+	if err != nil {
+		return Request{}, err
+	}
+	query := req.URL.Query()
+	for key, values := range rd.QueryParams {
+		query[key] = append(query[key], values...)
+	}
+	req.URL.RawQuery = query.Encode()
+
+	for key, values := range rd.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+		req.Header.Del("Host")
+	}
+	if _, ok := rd.Body.(string); rd.Body != nil && !ok && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.RemoteAddr = "127.0.0.1:0"
+	if rd.Origin != "" {
+		req.RemoteAddr = net.JoinHostPort(rd.Origin, "0")
+	}
+	return HTTPRequestToRequest(req), nil
+}
+
+// MatchResult is the outcome of matching a request against the mocks of a session without side
+// effects: the mock that would answer, the mocks that would be skipped, and the response it would
+// return. Proxy mocks are not called, so their Response is empty.
+type MatchResult struct {
+	Message  string        `json:"message" yaml:"message"`
+	Request  Request       `json:"request" yaml:"request"`
+	Mock     *Mock         `json:"mock,omitempty" yaml:"mock,omitempty"`
+	MockType string        `json:"mock_type,omitempty" yaml:"mock_type,omitempty"`
+	Response *MockResponse `json:"response,omitempty" yaml:"response,omitempty"`
+	// ResponseIndex is the index of the response which would be picked in the responses list.
+	ResponseIndex *int   `json:"response_index,omitempty" yaml:"response_index,omitempty"`
+	Error         string `json:"error,omitempty" yaml:"error,omitempty"`
+	// Skipped reports why the mocks checked before the matching one didn't match.
+	Skipped  []MatchReport `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	Exceeded Mocks         `json:"exceeded,omitempty" yaml:"exceeded,omitempty"`
+	// Closest reports why the closest mocks didn't match when no mock matches.
+	Closest []MatchReport `json:"closest,omitempty" yaml:"closest,omitempty"`
+}
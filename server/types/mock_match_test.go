@@ -0,0 +1,93 @@
+package types
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestFirstMatch(t *testing.T) {
+	var mocks Mocks
+	err := yaml.Unmarshal([]byte(`
+- request:
+    method: POST
+    path: /users
+    body:
+      name: alice
+  response:
+    status: 201
+  context:
+    times: 1
+- request:
+    method: POST
+    path: /users
+  response:
+    status: 400
+- request:
+    method: GET
+    path: /users
+  response:
+    status: 200
+`), &mocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mock := range mocks {
+		if err := mock.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		mock.Init()
+	}
+	// The last declared mock comes first.
+	mocks[0], mocks[2] = mocks[2], mocks[0]
+
+	req, err := RequestDescription{
+		Method: "post",
+		Path:   "/users?debug=true",
+		Body:   map[string]interface{}{"name": "alice"},
+	}.Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodPost || req.Path != "/users" || req.Headers.Get("Content-Type") != "application/json" {
+		t.Errorf("request = %+v", req)
+	}
+	if !reflect.DeepEqual(req.Body, map[string]interface{}{"name": "alice"}) || req.QueryParams.Get("debug") != "true" {
+		t.Errorf("request = %+v", req)
+	}
+
+	mock, exceeded := mocks.FirstMatch(req)
+	if mock != mocks[1] || len(exceeded) != 0 {
+		t.Fatalf("matching mock = %+v, exceeded = %+v", mock, exceeded)
+	}
+	if mocks[1].State.TimesCount != 0 {
+		t.Error("FirstMatch should not update the mocks state")
+	}
+
+	// Swap the declaration order so the times limited mock comes first, then exceed it.
+	mocks[1], mocks[2] = mocks[2], mocks[1]
+	if mock, _ := mocks.FirstMatch(req); mock != mocks[1] {
+		t.Fatalf("matching mock = %+v", mock)
+	}
+	mocks[1].State.TimesCount = 1
+	mock, exceeded = mocks.FirstMatch(req)
+	if mock != mocks[2] || len(exceeded) != 1 || exceeded[0] != mocks[1] {
+		t.Errorf("matching mock = %+v, exceeded = %+v", mock, exceeded)
+	}
+
+	if _, err := (RequestDescription{Path: "users"}).Request(); err == nil {
+		t.Error("a path without leading slash should be rejected")
+	}
+
+	for _, origin := range []string{"10.0.0.1", "::1"} {
+		req, err := RequestDescription{Path: "/", Origin: origin}.Request()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req.Origin != origin {
+			t.Errorf("origin = %q, want %q", req.Origin, origin)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+)
+
+type ResponsesMode string
+
+const (
+	SequenceResponses ResponsesMode = "sequence"
+	RandomResponses   ResponsesMode = "random"
+)
+
+// MockResponses is a list of static responses a mock answers with. In sequence mode, the default,
+// each call gets the next response of the list, and once the list is exhausted, the last one, or
+// the first one again when Loop is set. In random mode, each call gets a response picked at random
+// according to the weights of the responses (1 by default).
+type MockResponses struct {
+	Mode ResponsesMode          `json:"mode,omitempty" yaml:"mode,omitempty"`
+	Loop bool                   `json:"loop,omitempty" yaml:"loop,omitempty"`
+	List []WeightedMockResponse `json:"list" yaml:"list"`
+}
+
+type WeightedMockResponse struct {
+	MockResponse `yaml:",inline"`
+	Weight       int `json:"weight,omitempty" yaml:"weight,omitempty"`
+}
+
+// mockResponses is MockResponses without its unmarshalers.
+type mockResponses MockResponses
+
+func (mr *MockResponses) UnmarshalJSON(data []byte) error {
+	// List form: the responses of a sequence.
+	var list []WeightedMockResponse
+	if err := json.Unmarshal(data, &list); err == nil {
+		*mr = MockResponses{List: list}
+		return nil
+	}
+
+	var res mockResponses
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+	*mr = MockResponses(res)
+	return nil
+}
+
+func (mr *MockResponses) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []WeightedMockResponse
+	if err := unmarshal(&list); err == nil {
+		*mr = MockResponses{List: list}
+		return nil
+	}
+
+	var res mockResponses
+	if err := unmarshal(&res); err != nil {
+		return err
+	}
+	*mr = MockResponses(res)
+	return nil
+}
+
+func (mr MockResponses) Validate() error {
+	if len(mr.List) == 0 {
+		return errors.New("The responses list must define at least a response")
+	}
+	switch mr.Mode {
+	case "", SequenceResponses:
+	case RandomResponses:
+		if mr.Loop {
+			return errors.New("The responses loop option is only available in sequence mode")
+		}
+	default:
+		return fmt.Errorf("The responses mode must be one of the following: %v", []ResponsesMode{SequenceResponses, RandomResponses})
+	}
+	for i, response := range mr.List {
+		if response.Weight < 0 {
+			return fmt.Errorf("The weight of response %d must be greater than or equal to 0", i)
+		}
+		if response.Weight != 0 && mr.Mode != RandomResponses {
+			return fmt.Errorf("The weight of response %d is only used in random mode", i)
+		}
+		if err := response.Validate(); err != nil {
+			return fmt.Errorf("invalid response %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Pick returns the index of the response answering the call-th call (starting at 0) of the mock.
+func (mr MockResponses) Pick(call int) int {
+	if mr.Mode == RandomResponses {
+		total := 0
+		for _, response := range mr.List {
+			total += response.weight()
+		}
+		n := rand.IntN(total)
+		for i, response := range mr.List {
+			if n -= response.weight(); n < 0 {
+				return i
+			}
+		}
+	}
+	switch {
+	case call < len(mr.List):
+		return call
+	case mr.Loop:
+		return call % len(mr.List)
+	default:
+		return len(mr.List) - 1
+	}
+}
+
+func (wr WeightedMockResponse) weight() int {
+	if wr.Weight == 0 {
+		return 1
+	}
+	return wr.Weight
+}
@@ -0,0 +1,101 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMockResponses(t *testing.T) {
+	schema := compileMockSchema(t)
+	for name, doc := range map[string]string{
+		"sequence": `
+request:
+  path: /flaky
+responses:
+  - status: 503
+  - status: 200
+`,
+		"random": `
+request:
+  path: /flaky
+responses:
+  mode: random
+  list:
+    - status: 500
+      weight: 1
+    - status: 200
+      weight: 9
+`,
+	} {
+		if err := schema.Validate(asJSONValue(t, []byte(doc))); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		var mock Mock
+		if err := yaml.Unmarshal([]byte(doc), &mock); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := mock.Validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if len(mock.Responses.List) != 2 || mock.Responses.List[0].Status == 200 {
+			t.Errorf("%s: responses = %+v", name, mock.Responses)
+		}
+	}
+
+	var responses MockResponses
+	if err := json.Unmarshal([]byte(`[{"status": 503}, {"status": 200, "body": "ok"}]`), &responses); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses.List) != 2 || responses.List[1].Body != "ok" {
+		t.Errorf("responses = %+v", responses)
+	}
+
+	for name, responses := range map[string]MockResponses{
+		"empty list":         {},
+		"unknown mode":       {Mode: "round_robin", List: []WeightedMockResponse{{}}},
+		"loop in random":     {Mode: RandomResponses, Loop: true, List: []WeightedMockResponse{{}}},
+		"weight in sequence": {List: []WeightedMockResponse{{Weight: 2}}},
+		"negative weight":    {Mode: RandomResponses, List: []WeightedMockResponse{{Weight: -1}}},
+		"invalid response":   {List: []WeightedMockResponse{{MockResponse: MockResponse{BodyBase64: "!"}}}},
+	} {
+		if err := responses.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	list := &MockResponses{List: []WeightedMockResponse{{}}}
+	for name, mock := range map[string]Mock{
+		"with a response":         {Responses: list, Response: &MockResponse{}},
+		"with a dynamic response": {Responses: list, DynamicResponse: &DynamicMockResponse{Engine: LuaEngineID, Script: "return {}"}},
+		"with a proxy":            {Responses: list, Proxy: &MockProxy{Host: "http://localhost"}},
+	} {
+		if err := mock.Validate(); err == nil {
+			t.Errorf("responses %s: expected an error", name)
+		}
+	}
+}
+
+func TestMockResponsesPick(t *testing.T) {
+	list := []WeightedMockResponse{{}, {}, {}}
+	stick := MockResponses{List: list}
+	loop := MockResponses{Loop: true, List: list}
+	for call, expected := range []struct{ stick, loop int }{{0, 0}, {1, 1}, {2, 2}, {2, 0}, {2, 1}} {
+		if index := stick.Pick(call); index != expected.stick {
+			t.Errorf("sequence call %d: index = %d, want %d", call, index, expected.stick)
+		}
+		if index := loop.Pick(call); index != expected.loop {
+			t.Errorf("loop call %d: index = %d, want %d", call, index, expected.loop)
+		}
+	}
+
+	random := MockResponses{Mode: RandomResponses, List: []WeightedMockResponse{{Weight: 1}, {Weight: 99}}}
+	counts := make([]int, 2)
+	for call := 0; call < 1000; call++ {
+		counts[random.Pick(call)]++
+	}
+	if counts[1] < counts[0]*5 {
+		t.Errorf("random picks = %v, expected the weights to be followed", counts)
+	}
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// MultipartForm is the parsed content of a multipart/form-data request body. Files are described
+// by their metadata and a content hash rather than their content, which stays in the raw body.
+type MultipartForm struct {
+	Fields map[string][]string        `json:"fields,omitempty" yaml:"fields,omitempty"`
+	Files  map[string][]MultipartFile `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+type MultipartFile struct {
+	Filename    string `json:"filename" yaml:"filename"`
+	ContentType string `json:"content_type,omitempty" yaml:"content_type,omitempty"`
+	Size        int64  `json:"size" yaml:"size"`
+	SHA256      string `json:"sha256" yaml:"sha256"`
+}
+
+// ParseMultipartForm parses body according to the boundary of a multipart/form-data Content-Type.
+// It returns a nil form for any other Content-Type.
+func ParseMultipartForm(headers http.Header, body string) (*MultipartForm, error) {
+	mediaType, params, err := mime.ParseMediaType(headers.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil, nil
+	}
+
+	form := &MultipartForm{
+		Fields: map[string][]string{},
+		Files:  map[string][]MultipartFile{},
+	}
+	reader := multipart.NewReader(strings.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := part.FormName()
+		if part.FileName() == "" {
+			value, err := io.ReadAll(part)
+			if err != nil {
+				return nil, err
+			}
+			form.Fields[name] = append(form.Fields[name], string(value))
+			continue
+		}
+
+		hash := sha256.New()
+		size, err := io.Copy(hash, part)
+		if err != nil {
+			return nil, err
+		}
+		form.Files[name] = append(form.Files[name], MultipartFile{
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Size:        size,
+			SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		})
+	}
+}
@@ -31,6 +31,21 @@ type Session struct {
 	Date    time.Time `json:"date"`
 	History History   `json:"history"`
 	Mocks   Mocks     `json:"mocks"`
+
+	index *MockIndex
+}
+
+// MockIndex returns the index of the mocks of the session, built on first use. ResetMockIndex must
+// be called whenever the mocks change.
+func (s *Session) MockIndex() *MockIndex {
+	if s.index == nil {
+		s.index = NewMockIndex(s.Mocks)
+	}
+	return s.index
+}
+
+func (s *Session) ResetMockIndex() {
+	s.index = nil
 }
 
 func (s *Session) Clone() *Session {
@@ -44,7 +59,13 @@ func (s *Session) Clone() *Session {
 }
 
 func (s Session) Summarize() SessionSummary {
-	return SessionSummary(s)
+	return SessionSummary{
+		ID:      s.ID,
+		Name:    s.Name,
+		Date:    s.Date,
+		History: s.History,
+		Mocks:   s.Mocks,
+	}
 }
 
 type SessionSummary struct {
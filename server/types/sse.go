@@ -0,0 +1,136 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SSEResponse streams server-sent events (text/event-stream), each one sent after its own delay,
+// counted from the previous event.
+type SSEResponse struct {
+	Events   []SSEEvent `json:"events,omitempty" yaml:"events,omitempty"`
+	KeepOpen KeepOpen   `json:"keep_open,omitempty" yaml:"keep_open,omitempty"`
+}
+
+type SSEEvent struct {
+	ID    string `json:"id,omitempty" yaml:"id,omitempty"`
+	Event string `json:"event,omitempty" yaml:"event,omitempty"`
+	Data  string `json:"data,omitempty" yaml:"data,omitempty"`
+	// Retry is the reconnection time, in milliseconds, the client should use.
+	Retry int   `json:"retry,omitempty" yaml:"retry,omitempty"`
+	Delay Delay `json:"delay,omitempty" yaml:"delay,omitempty"`
+}
+
+// SentEvent is a server-sent event, as it was sent.
+type SentEvent struct {
+	ID    string    `json:"id,omitempty" yaml:"id,omitempty"`
+	Event string    `json:"event,omitempty" yaml:"event,omitempty"`
+	Data  string    `json:"data,omitempty" yaml:"data,omitempty"`
+	Retry int       `json:"retry,omitempty" yaml:"retry,omitempty"`
+	Date  time.Time `json:"date" yaml:"date"`
+}
+
+func (sr SSEResponse) Validate() error {
+	if len(sr.Events) == 0 && sr.KeepOpen == 0 {
+		return errors.New("The sse response must define at least an event, or keep the stream open")
+	}
+	for i, event := range sr.Events {
+		if strings.ContainsAny(event.ID, "\r\n") || strings.ContainsAny(event.Event, "\r\n") {
+			return fmt.Errorf("The id and event of sse event %d must fit on a single line", i)
+		}
+		if event.Retry < 0 {
+			return fmt.Errorf("The retry of sse event %d must be greater than or equal to 0", i)
+		}
+		if err := event.Delay.validate(); err != nil {
+			return fmt.Errorf("invalid sse event %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Format returns the event in the text/event-stream format, a data line for each line of its data.
+func (e SSEEvent) Format() []byte {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		fmt.Fprintf(&buf, "id: %s\n", e.ID)
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", e.Event)
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&buf, "retry: %d\n", e.Retry)
+	}
+	if e.Data != "" {
+		for _, line := range strings.Split(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\n") {
+			fmt.Fprintf(&buf, "data: %s\n", line)
+		}
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+func (e SSEEvent) Sent() SentEvent {
+	return SentEvent{ID: e.ID, Event: e.Event, Data: e.Data, Retry: e.Retry, Date: time.Now()}
+}
+
+// KeepOpen tells how long a stream stays open once its events are sent: not at all (false), until
+// the client disconnects (true), or until the client disconnects or a duration elapses (e.g. "30s").
+type KeepOpen time.Duration
+
+const KeepOpenUntilDisconnect KeepOpen = -1
+
+func (ko KeepOpen) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ko.value())
+}
+
+func (ko KeepOpen) MarshalYAML() (interface{}, error) {
+	return ko.value(), nil
+}
+
+func (ko KeepOpen) value() interface{} {
+	if ko > 0 {
+		return time.Duration(ko).String()
+	}
+	return ko == KeepOpenUntilDisconnect
+}
+
+func (ko *KeepOpen) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return ko.parse(value)
+}
+
+func (ko *KeepOpen) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value interface{}
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return ko.parse(value)
+}
+
+func (ko *KeepOpen) parse(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*ko = 0
+	case bool:
+		*ko = 0
+		if v {
+			*ko = KeepOpenUntilDisconnect
+		}
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid keep_open %q, expected true, false or a positive duration", v)
+		}
+		*ko = KeepOpen(d)
+	default:
+		return fmt.Errorf("invalid keep_open %v, expected true, false or a positive duration", value)
+	}
+	return nil
+}
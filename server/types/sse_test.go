@@ -0,0 +1,57 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestKeepOpen(t *testing.T) {
+	for _, test := range []struct {
+		yaml     string
+		expected KeepOpen
+	}{
+		{"false", 0},
+		{"true", KeepOpenUntilDisconnect},
+		{"30s", KeepOpen(30 * time.Second)},
+	} {
+		var ko KeepOpen
+		if err := yaml.Unmarshal([]byte(test.yaml), &ko); err != nil || ko != test.expected {
+			t.Errorf("%s: keep_open = %v, err = %v", test.yaml, ko, err)
+			continue
+		}
+		b, err := json.Marshal(ko)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var roundTrip KeepOpen
+		if err := json.Unmarshal(b, &roundTrip); err != nil || roundTrip != test.expected {
+			t.Errorf("%s: round trip through %s = %v, err = %v", test.yaml, b, roundTrip, err)
+		}
+	}
+
+	for _, invalid := range []string{"forever", "-1s", "42"} {
+		var ko KeepOpen
+		if err := yaml.Unmarshal([]byte(invalid), &ko); err == nil {
+			t.Errorf("%s: expected an error", invalid)
+		}
+	}
+}
+
+func TestSSEResponseValidate(t *testing.T) {
+	for name, response := range map[string]MockResponse{
+		"no events":         {SSE: &SSEResponse{}},
+		"multi-line id":     {SSE: &SSEResponse{Events: []SSEEvent{{ID: "1\n2"}}}},
+		"negative retry":    {SSE: &SSEResponse{Events: []SSEEvent{{Retry: -1}}}},
+		"both body and sse": {Body: "text", SSE: &SSEResponse{Events: []SSEEvent{{Data: "ping"}}}},
+	} {
+		if err := response.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := (MockResponse{SSE: &SSEResponse{KeepOpen: KeepOpenUntilDisconnect}}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MockWebSocket is a scripted WebSocket conversation, held once the upgrade request matched. The
+// OnConnect frames are sent in turn, each one after its own delay, and the Pushes are sent
+// repeatedly while the connection is open. Each inbound frame is answered by the first of the
+// OnMessage replies matching it. The connection is closed by the client, by a reply, or by Close.
+type MockWebSocket struct {
+	OnConnect []WebSocketFrame `json:"on_connect,omitempty" yaml:"on_connect,omitempty"`
+	OnMessage []WebSocketReply `json:"on_message,omitempty" yaml:"on_message,omitempty"`
+	Pushes    []WebSocketPush  `json:"pushes,omitempty" yaml:"pushes,omitempty"`
+	Close     *WebSocketClose  `json:"close,omitempty" yaml:"close,omitempty"`
+}
+
+// WebSocketFrame is a text frame, or a binary frame when its body is declared in BodyBase64.
+type WebSocketFrame struct {
+	Body       string `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyBase64 string `json:"body_base64,omitempty" yaml:"body_base64,omitempty"`
+	Delay      Delay  `json:"delay,omitempty" yaml:"delay,omitempty"`
+}
+
+// WebSocketReply answers the inbound frames matching Body, any frame when Body is not declared.
+// Binary frames are matched base64 encoded.
+type WebSocketReply struct {
+	Body  *StringMatcher   `json:"body,omitempty" yaml:"body,omitempty"`
+	Send  []WebSocketFrame `json:"send,omitempty" yaml:"send,omitempty"`
+	Close *WebSocketClose  `json:"close,omitempty" yaml:"close,omitempty"`
+}
+
+type WebSocketPush struct {
+	WebSocketFrame `yaml:",inline"`
+	Every          Delay `json:"every" yaml:"every"`
+}
+
+// WebSocketClose closes the connection with a status code (1000 by default) and a reason, after
+// its delay.
+type WebSocketClose struct {
+	Code   int    `json:"code,omitempty" yaml:"code,omitempty"`
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Delay  Delay  `json:"delay,omitempty" yaml:"delay,omitempty"`
+}
+
+// WebSocketMessage is a frame of a WebSocket conversation, as it was sent or received. Direction
+// is "in" for the frames sent by the client, "out" for the ones sent by Smocker.
+type WebSocketMessage struct {
+	Direction  string    `json:"direction" yaml:"direction"`
+	Type       string    `json:"type" yaml:"type"`
+	Body       string    `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyBase64 bool      `json:"body_base64,omitempty" yaml:"body_base64,omitempty"`
+	Code       int       `json:"code,omitempty" yaml:"code,omitempty"`
+	Date       time.Time `json:"date" yaml:"date"`
+}
+
+func (mw MockWebSocket) Validate() error {
+	for i, frame := range mw.OnConnect {
+		if err := frame.Validate(); err != nil {
+			return fmt.Errorf("invalid on_connect frame %d: %w", i, err)
+		}
+	}
+	for i, reply := range mw.OnMessage {
+		if reply.Body != nil {
+			if err := reply.Body.Validate(); err != nil {
+				return fmt.Errorf("invalid on_message reply %d: %w", i, err)
+			}
+		}
+		for j, frame := range reply.Send {
+			if err := frame.Validate(); err != nil {
+				return fmt.Errorf("invalid on_message reply %d frame %d: %w", i, j, err)
+			}
+		}
+		if reply.Close != nil {
+			if err := reply.Close.Validate(); err != nil {
+				return fmt.Errorf("invalid on_message reply %d: %w", i, err)
+			}
+		}
+	}
+	for i, push := range mw.Pushes {
+		if push.Every.Min <= 0 {
+			return fmt.Errorf("invalid push %d: every must be greater than 0", i)
+		}
+		if err := push.Validate(); err != nil {
+			return fmt.Errorf("invalid push %d: %w", i, err)
+		}
+	}
+	if mw.Close != nil {
+		return mw.Close.Validate()
+	}
+	return nil
+}
+
+func (wf WebSocketFrame) Validate() error {
+	if wf.Body != "" && wf.BodyBase64 != "" {
+		return errors.New("a frame must define either a body or a body_base64, not both of them")
+	}
+	_, err := wf.BodyBytes()
+	return err
+}
+
+// BodyBytes returns the body of the frame, decoding BodyBase64 when it is set.
+func (wf WebSocketFrame) BodyBytes() ([]byte, error) {
+	return decodeBody(wf.Body, wf.BodyBase64)
+}
+
+func (wf WebSocketFrame) IsBinary() bool {
+	return wf.BodyBase64 != ""
+}
+
+func (wc WebSocketClose) Validate() error {
+	// 1005, 1006 and 1015 are reserved for the endpoints, they can't be sent.
+	if wc.Code != 0 && (wc.Code < 1000 || wc.Code > 4999 || wc.Code == 1005 || wc.Code == 1006 || wc.Code == 1015) {
+		return fmt.Errorf("invalid close code %d", wc.Code)
+	}
+	if len(wc.Reason) > 123 {
+		return errors.New("the close reason must not exceed 123 bytes")
+	}
+	return nil
+}
+
+// StatusCode returns the code of the close frame, 1000 (normal closure) by default.
+func (wc WebSocketClose) StatusCode() int {
+	if wc.Code == 0 {
+		return 1000
+	}
+	return wc.Code
+}
+
+// Reply returns the first reply matching an inbound frame, nil when none does.
+func (mw MockWebSocket) Reply(body string) *WebSocketReply {
+	for i, reply := range mw.OnMessage {
+		if reply.Body == nil || reply.Body.Match(body) {
+			return &mw.OnMessage[i]
+		}
+	}
+	return nil
+}
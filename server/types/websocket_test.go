@@ -0,0 +1,63 @@
+package types
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMockWebSocket(t *testing.T) {
+	doc := `
+request:
+  path: /ws
+websocket:
+  on_connect:
+    - body: hello
+      delay: 10ms
+  on_message:
+    - body: {matcher: ShouldStartWith, value: ping}
+      send:
+        - body: pong
+    - close: {code: 4000, reason: bye}
+  pushes:
+    - body_base64: AAEC
+      every: 1s
+  close:
+    delay: 1m
+`
+	if err := compileMockSchema(t).Validate(asJSONValue(t, []byte(doc))); err != nil {
+		t.Error(err)
+	}
+	var mock Mock
+	if err := yaml.Unmarshal([]byte(doc), &mock); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if reply := mock.WebSocket.Reply("ping 1"); reply == nil || len(reply.Send) != 1 {
+		t.Errorf("reply = %+v", reply)
+	}
+	// A reply without body matcher answers any frame.
+	if reply := mock.WebSocket.Reply("other"); reply == nil || reply.Close.StatusCode() != 4000 {
+		t.Errorf("reply = %+v", reply)
+	}
+	if code := mock.WebSocket.Close.StatusCode(); code != 1000 {
+		t.Errorf("close code = %d, want 1000", code)
+	}
+
+	for name, ws := range map[string]MockWebSocket{
+		"both bodies":    {OnConnect: []WebSocketFrame{{Body: "a", BodyBase64: "YQ=="}}},
+		"invalid base64": {OnConnect: []WebSocketFrame{{BodyBase64: "!"}}},
+		"invalid reply":  {OnMessage: []WebSocketReply{{Body: &StringMatcher{Matcher: "ShouldDoSomething", Value: "a"}}}},
+		"push no period": {Pushes: []WebSocketPush{{WebSocketFrame: WebSocketFrame{Body: "a"}}}},
+		"reserved code":  {Close: &WebSocketClose{Code: 1006}},
+	} {
+		if err := ws.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := (&Mock{WebSocket: &MockWebSocket{}, Response: &MockResponse{}}).Validate(); err == nil {
+		t.Error("expected an error for a mock with both a websocket and a response")
+	}
+}